@@ -0,0 +1,184 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/asciimoth/version/pkg/version"
+)
+
+// Comparison operator names -> accepted results of semver.Version.Compare.
+var compareOps = map[string][]int{
+	"lt": {-1},
+	"le": {-1, 0},
+	"eq": {0},
+	"ne": {-1, 1},
+	"ge": {0, 1},
+	"gt": {1},
+}
+
+// Operator aliases.
+var compareOpAliases = map[string]string{
+	"<":  "lt",
+	"<=": "le",
+	"=":  "eq",
+	"==": "eq",
+	"!=": "ne",
+	">=": "ge",
+	">":  "gt",
+}
+
+// Handles positional args that are shorthands for subcommand flags,
+// e.g. constraint of `satisfies`, operator of `compare` or action of
+// `config` and `completion`.
+// Args of commands accepting constraints are constraints unless they are
+// something else, so malformed ones are reported as such.
+func parsePositionalFlag(cmd, arg string, flags map[string]string) (bool, error) {
+	if slices.Contains(commandFlags[cmd], "action=") && flags["action"] == "" {
+		if slices.Contains(commandActions(cmd), arg) {
+			flags["action"] = arg
+			return true, nil
+		}
+	}
+	if slices.Contains(commandFlags[cmd], "op=") {
+		op := strings.ToLower(strings.TrimSpace(arg))
+		op = cmp.Or(compareOpAliases[op], op)
+		if _, ok := compareOps[op]; ok {
+			flags["op"] = op
+			return true, nil
+		}
+	}
+	if slices.Contains(commandFlags[cmd], "constraint=") {
+		if _, err := semver.NewConstraint(arg); err != nil {
+			return false, fmt.Errorf("invalid constraint %q: %w", arg, err)
+		}
+		if prev, ok := flags["constraint"]; ok {
+			arg = prev + ", " + arg
+		}
+		flags["constraint"] = arg
+		return true, nil
+	}
+	return false, nil
+}
+
+// Returns names of actions of subcommand like `config validate`.
+func commandActions(cmd string) []string {
+	switch cmd {
+	case "config":
+		return slices.Collect(maps.Keys(configActions))
+	case "completion":
+		return slices.Concat(
+			slices.Collect(maps.Keys(completionShells)),
+			slices.Collect(maps.Keys(completionActions)),
+		)
+	}
+	return nil
+}
+
+// Resolves operand that is either source name or version literal.
+func resolveOperand(group version.SourceGroup, op string) (*semver.Version, error) {
+	if !version.IsSourceName(op) {
+		return group.VersionScheme().Parse(op)
+	}
+	if _, ok := group.Sources[op]; !ok {
+		return nil, fmt.Errorf("unknown source %s", op)
+	}
+	reports, _, err := group.Fetch([]version.Name{op})
+	if err != nil {
+		return nil, err
+	}
+	if len(reports) < 1 || reports[0].Version == nil {
+		return nil, fmt.Errorf("%s reports no version", op)
+	}
+	return reports[0].Version, nil
+}
+
+// `satisfies` subcommand handler.
+// Exits with 0 if version satisfies constraint, 1 if not and 2 on errors.
+func cmdSatisfies(
+	group version.SourceGroup,
+	_ []string,
+	srcs []string,
+	ver []semver.Version,
+	opts cmdOpts,
+	_ io.Writer,
+) (int, error) {
+	constraint, ok := opts.flags["constraint"]
+	if !ok {
+		return 2, errors.New("constraint expected, e.g. \">=1.4, <2\"")
+	}
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return 2, err
+	}
+	var v *semver.Version
+	if len(opts.operands) == 0 {
+		v, err = group.Get(nil)
+	} else {
+		v, err = group.GetMax(srcs, ver)
+	}
+	if err != nil {
+		return 2, err
+	}
+	ok, errs := c.Validate(v)
+	if ok {
+		group.Log(fmt.Sprintf("%s satisfies %s", group.Format(v), constraint))
+		return 0, nil
+	}
+	for _, e := range errs {
+		group.Log(e.Error())
+	}
+	return 1, nil
+}
+
+// `compare` subcommand handler.
+// Without operator prints -1, 0 or 1 if first operand is lesser, equal
+// or greater than second one. With operator exits with 0 if comparison
+// holds, 1 if not and 2 on errors.
+func cmdCompare(
+	group version.SourceGroup,
+	_ []string,
+	_ []string,
+	_ []semver.Version,
+	opts cmdOpts,
+	out io.Writer,
+) (int, error) {
+	if len(opts.operands) != 2 {
+		return 2, errors.New("exactly two versions or sources expected")
+	}
+	a, err := resolveOperand(group, opts.operands[0])
+	if err != nil {
+		return 2, err
+	}
+	b, err := resolveOperand(group, opts.operands[1])
+	if err != nil {
+		return 2, err
+	}
+	res := a.Compare(b)
+	op, ok := opts.flags["op"]
+	if !ok {
+		_, err := fmt.Fprintln(out, res)
+		if err != nil {
+			return 2, err
+		}
+		return 0, nil
+	}
+	op = cmp.Or(compareOpAliases[op], strings.ToLower(op))
+	accepted, ok := compareOps[op]
+	if !ok {
+		return 2, fmt.Errorf("unknown comparison operator %s", op)
+	}
+	desc := fmt.Sprintf("%s %s %s", group.Format(a), op, group.Format(b))
+	if slices.Contains(accepted, res) {
+		group.Log(desc + ": true")
+		return 0, nil
+	}
+	group.Log(desc + ": false")
+	return 1, nil
+}
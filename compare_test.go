@@ -0,0 +1,62 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Runs command in new project with config, returns exit code.
+func runInProject(t *testing.T, config string, args ...string) int {
+	t.Helper()
+	dir := newProject(t, config)
+	code, _ := routeCmd(args, dir, nil, io.Discard, io.Discard)
+	return code
+}
+
+// Creates git repository with config and makes it working dir.
+func newProject(t *testing.T, config string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if config != "" {
+		err := os.WriteFile(filepath.Join(dir, "version.toml"), []byte(config), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+	return dir
+}
+
+func TestSatisfiesAndCompareExitCodes(t *testing.T) {
+	valid := `DefaultVersion = "1.5.0"` + "\n"
+	tests := []struct {
+		config string
+		args   []string
+		code   int
+	}{
+		{valid, []string{"satisfies", ">=1.4, <2"}, 0},
+		{valid, []string{"satisfies", "^2"}, 1},
+		{valid, []string{"satisfies", ">=1.4, <2x"}, 2},
+		{valid, []string{"satisfies"}, 2},
+		{valid, []string{"satisfies", "--bogus", "^1"}, 2},
+		{"Sources = 3\n", []string{"satisfies", "^1"}, 2},
+		{valid, []string{"compare", "1.0.0", "lt", "2.0.0"}, 0},
+		{valid, []string{"compare", "2.0.0", "lt", "1.0.0"}, 1},
+		{valid, []string{"compare", "1.0.0", "lt"}, 2},
+		{valid, []string{"compare", "1.0.0", "lt", "2.0.0", "--bogus"}, 2},
+		{"Sources = 3\n", []string{"compare", "1.0.0", "2.0.0"}, 2},
+		// Other commands keep 1 for errors
+		{"Sources = 3\n", []string{"get"}, 1},
+		{valid, []string{"sort", "<2x", "1.0.0"}, 1},
+	}
+	for _, tt := range tests {
+		if code := runInProject(t, tt.config, tt.args...); code != tt.code {
+			t.Errorf("%q: exit code %d, want %d", tt.args, code, tt.code)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/asciimoth/version/pkg/version"
+)
+
+// Completion script templates.
+var (
+	//go:embed completions/bash.tmpl
+	completionBash string
+	//go:embed completions/zsh.tmpl
+	completionZsh string
+	//go:embed completions/fish.tmpl
+	completionFish string
+)
+
+// Mapping shell name -> completion script template.
+var completionShells = map[string]string{
+	"bash": completionBash,
+	"zsh":  completionZsh,
+	"fish": completionFish,
+}
+
+// Mapping `completion` action name -> it's implementation.
+// Scripts call `sources` and `groups` actions to complete names from
+// config of current project.
+var completionActions = map[string]func(version.SourceGroup, io.Writer) error{
+	"sources": func(group version.SourceGroup, out io.Writer) error {
+		return printLines(out, slices.Sorted(maps.Keys(group.Sources)))
+	},
+	"groups": func(group version.SourceGroup, out io.Writer) error {
+		return printLines(out, slices.Sorted(maps.Keys(group.Groups)))
+	},
+}
+
+// Completion lists commands, so it is registered after commands map is
+// initialized.
+func init() {
+	commands["completion"] = cmdCompletion
+	commandFlags["completion"] = []string{"action="}
+}
+
+// Value flags scripts complete on their own: files, dirs and names.
+var completedFlags = []string{"config", "root", "source", "group"}
+
+// Commands without source operands.
+var noSourceCommands = []string{"init", "config", "completion"}
+
+// Line of command list in main help, e.g. "  get   Fetch versions...".
+var helpCommandLine = regexp.MustCompile(`^  ([a-z]+)\s+(.+)$`)
+
+// Data passed to completion script templates.
+type completionData struct {
+	Commands []completionItem
+	// Flags with leading dashes
+	GlobalFlags []completionFlag
+	// Command -> its own flags
+	CommandFlags map[string][]completionFlag
+	// Command -> positional keywords, e.g. version parts
+	CommandWords map[string][]string
+	// Names of flags taking free-form values, nothing is completed for them
+	ValueFlags []string
+	// Commands without source operands
+	NoSources []string
+}
+
+type completionItem struct {
+	Name, Desc string
+}
+
+type completionFlag struct {
+	Name  string
+	Short string
+	Value bool
+}
+
+// `completion` subcommand handler.
+// Unlike other commands it runs even if config failed to load.
+func cmdCompletion(
+	group version.SourceGroup,
+	_ []string,
+	_ []string,
+	ver []semver.Version,
+	opts cmdOpts,
+	out io.Writer,
+) (int, error) {
+	if len(ver) > 0 || len(opts.operands) > 0 {
+		return 1, errors.New("this command accepts no version or source args")
+	}
+	action, ok := opts.flags["action"]
+	if !ok {
+		return 1, fmt.Errorf(
+			"missing shell, expected one of: %v",
+			slices.Sorted(maps.Keys(completionShells)),
+		)
+	}
+	if f, ok := completionActions[action]; ok {
+		// Scripts expect no names rather than error
+		if opts.loadErr != nil {
+			return 0, nil
+		}
+		if err := f(group, out); err != nil {
+			return 1, err
+		}
+		return 0, nil
+	}
+	script, ok := completionShells[action]
+	if !ok {
+		return 1, fmt.Errorf(
+			"unknown shell %q, expected one of: %v",
+			action, slices.Sorted(maps.Keys(completionShells)),
+		)
+	}
+	tmpl, err := template.New(action).Funcs(template.FuncMap{
+		"join": strings.Join,
+	}).Parse(script)
+	if err != nil {
+		return 1, err
+	}
+	if err := tmpl.Execute(out, newCompletionData()); err != nil {
+		return 1, err
+	}
+	return 0, nil
+}
+
+func newCompletionData() completionData {
+	shorts := map[string]string{}
+	for short, long := range shortFlags {
+		shorts[long] = short
+	}
+	flags := func(specs []string) []completionFlag {
+		result := []completionFlag{}
+		for _, spec := range specs {
+			if spec == "-" {
+				continue
+			}
+			name, value := strings.CutSuffix(spec, "=")
+			result = append(result, completionFlag{name, shorts[name], value})
+		}
+		return result
+	}
+	descs := map[string]string{}
+	for line := range strings.SplitSeq(helpMain, "\n") {
+		if m := helpCommandLine.FindStringSubmatch(line); m != nil {
+			descs[m[1]] = m[2]
+		}
+	}
+	data := completionData{
+		GlobalFlags:  flags(globalFlags),
+		CommandFlags: map[string][]completionFlag{},
+		CommandWords: map[string][]string{
+			"get":        elements,
+			"bump":       elements,
+			"compare":    slices.Sorted(maps.Keys(compareOps)),
+			"config":     slices.Sorted(maps.Keys(configActions)),
+			"completion": slices.Sorted(maps.Keys(completionShells)),
+		},
+		NoSources: noSourceCommands,
+	}
+	for _, cmd := range slices.Sorted(maps.Keys(commands)) {
+		data.Commands = append(data.Commands, completionItem{cmd, descs[cmd]})
+		// Positional words are completed instead of action flag
+		data.CommandFlags[cmd] = slices.DeleteFunc(
+			flags(commandFlags[cmd]),
+			func(f completionFlag) bool { return f.Name == "action" },
+		)
+		for _, f := range append(data.GlobalFlags, data.CommandFlags[cmd]...) {
+			if f.Value && !slices.Contains(completedFlags, f.Name) &&
+				!slices.Contains(data.ValueFlags, f.Name) {
+				data.ValueFlags = append(data.ValueFlags, f.Name)
+			}
+		}
+	}
+	slices.Sort(data.ValueFlags)
+	return data
+}
+
+func printLines(out io.Writer, lines []string) error {
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(out, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
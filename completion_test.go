@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Scripts are generated without project, e.g. in build sandbox.
+func TestCompletionScriptsWithoutProject(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("VERSION_CONFIG", filepath.Join(dir, "missing.toml"))
+	for shell := range completionShells {
+		var out, errs bytes.Buffer
+		code, err := routeCmd([]string{"completion", shell}, dir, nil, &out, &errs)
+		if code != 0 || err != nil {
+			t.Errorf("completion %s: exit code %d, %v", shell, code, err)
+		}
+		if !strings.Contains(out.String(), "version") {
+			t.Errorf("completion %s: no script in output %q", shell, out.String())
+		}
+		if errs.Len() != 0 {
+			t.Errorf("completion %s: unexpected stderr %q", shell, errs.String())
+		}
+	}
+	// Names still come from project config, there are none without it
+	var out bytes.Buffer
+	code, _ := routeCmd([]string{"completion", "sources"}, dir, nil, &out, io.Discard)
+	if code != 0 || out.Len() != 0 {
+		t.Errorf("completion sources with missing config: exit code %d, output %q",
+			code, out.String())
+	}
+	code, _ = routeCmd([]string{"completion", "fish", "extra"}, dir, nil, io.Discard, io.Discard)
+	if code == 0 {
+		t.Error("completion with extra operand succeeded")
+	}
+	out.Reset()
+	code, err := routeCmd([]string{"completion", "--action", "foo"}, dir, nil, &out, io.Discard)
+	want := `unknown shell "foo", expected one of: [bash fish zsh]`
+	if code != 1 || err == nil || err.Error() != want || out.Len() != 0 {
+		t.Errorf("completion of unknown shell: exit code %d, error %v, output %q, want %q",
+			code, err, out.String(), want)
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/asciimoth/version/pkg/version"
+)
+
+// Mapping `config` action name -> it's implementation.
+var configActions = map[string]func(
+	version.SourceGroup, cmdOpts, io.Writer,
+) (int, error){
+	"validate": configValidate,
+	"schema":   configSchema,
+	"show":     configShow,
+	"explain":  configExplain,
+}
+
+// `config` subcommand handler.
+// Unlike other commands it runs even if config failed to load.
+func cmdConfig(
+	group version.SourceGroup,
+	_ []string,
+	_ []string,
+	ver []semver.Version,
+	opts cmdOpts,
+	out io.Writer,
+) (int, error) {
+	if len(ver) > 0 || len(opts.operands) > 0 {
+		return 1, errors.New("this command accepts no version or source args")
+	}
+	action, ok := opts.flags["action"]
+	if !ok {
+		return 1, fmt.Errorf(
+			"missing action, expected one of: %v",
+			slices.Sorted(maps.Keys(configActions)),
+		)
+	}
+	f, ok := configActions[action]
+	if !ok {
+		return 1, fmt.Errorf(
+			"unknown action %q, expected one of: %v",
+			action, slices.Sorted(maps.Keys(configActions)),
+		)
+	}
+	return f(group, opts, out)
+}
+
+// `config validate` prints every problem found in config.
+func configValidate(
+	_ version.SourceGroup,
+	opts cmdOpts,
+	out io.Writer,
+) (int, error) {
+	if opts.loadErr != nil {
+		_, err := fmt.Fprintln(out, opts.loadErr)
+		return 1, err
+	}
+	name := opts.config
+	if name == "" {
+		name = "default config"
+	}
+	_, err := fmt.Fprintln(out, name+": ok")
+	if err != nil {
+		return 1, err
+	}
+	return 0, nil
+}
+
+// Returns description of config file group is loaded from.
+func configOrigin(opts cmdOpts) string {
+	if opts.config == "" {
+		return "none, using default sources"
+	}
+	return opts.config
+}
+
+// `config show` prints resolved config as TOML or JSON with `--json`.
+func configShow(
+	group version.SourceGroup,
+	opts cmdOpts,
+	out io.Writer,
+) (int, error) {
+	if opts.loadErr != nil {
+		return 1, opts.loadErr
+	}
+	var text string
+	if opts.has("json") {
+		data, err := json.MarshalIndent(group.Config(), "", "  ")
+		if err != nil {
+			return 1, err
+		}
+		text = string(data) + "\n"
+	} else {
+		config, err := group.EncodeTOML()
+		if err != nil {
+			return 1, err
+		}
+		text = "# config: " + configOrigin(opts) + "\n" + config
+	}
+	_, err := io.WriteString(out, text)
+	if err != nil {
+		return 1, err
+	}
+	return 0, nil
+}
+
+// `config explain` prints config origin, sources with their options and
+// files they manage.
+func configExplain(
+	group version.SourceGroup,
+	opts cmdOpts,
+	out io.Writer,
+) (int, error) {
+	if opts.loadErr != nil {
+		return 1, opts.loadErr
+	}
+	defaultVersion := group.DefaultVersion
+	if defaultVersion == "" {
+		defaultVersion = "0.1.0 (default)"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "config: %s\n", configOrigin(opts))
+	fmt.Fprintf(&b, "root: %s\n", opts.root)
+	fmt.Fprintf(&b, "scheme: %s\n", cmp.Or(group.Scheme.Type, "semver"))
+	fmt.Fprintf(&b, "default version: %s\n", defaultVersion)
+	fmt.Fprintf(&b, "strict: %t\n", group.Strict)
+	fmt.Fprintf(&b, "ignored files: %s\n", globList(group.IgnoredFiles))
+	fmt.Fprintf(&b, "read-only files: %s\n", globList(group.ReadOnlyFiles))
+	for _, name := range slices.Sorted(maps.Keys(group.Sources)) {
+		src := group.Sources[name]
+		options := version.SourceConfig(src)
+		var notes []string
+		if src.Disabled {
+			notes = append(notes, "disabled")
+		}
+		if src.Source.IsReadOnly() {
+			notes = append(notes, "read-only")
+		}
+		fmt.Fprintf(&b, "\n%s: %s", name, options["Type"])
+		if len(notes) > 0 {
+			fmt.Fprintf(&b, " (%s)", strings.Join(notes, ", "))
+		}
+		b.WriteString("\n")
+		for _, key := range slices.Sorted(maps.Keys(options)) {
+			if key == "Type" || key == "Disabled" {
+				continue
+			}
+			val, err := json.Marshal(options[key])
+			if err != nil {
+				return 1, err
+			}
+			fmt.Fprintf(&b, "  %s = %s\n", key, val)
+		}
+		files, err := sourceFiles(group.FS(), src.Source)
+		if err != nil {
+			fmt.Fprintf(&b, "  files: %s\n", err)
+			continue
+		}
+		if len(files) == 0 {
+			b.WriteString("  files: none\n")
+			continue
+		}
+		b.WriteString("  files:\n")
+		for _, file := range files {
+			if group.IsReadOnlyFile(file) {
+				file += " (read-only)"
+			}
+			fmt.Fprintf(&b, "    %s\n", file)
+		}
+	}
+	_, err := io.WriteString(out, b.String())
+	if err != nil {
+		return 1, err
+	}
+	return 0, nil
+}
+
+func globList(globs []string) string {
+	if len(globs) == 0 {
+		return "none"
+	}
+	return strings.Join(globs, ", ")
+}
+
+// `config schema` prints JSON Schema of config.
+func configSchema(
+	_ version.SourceGroup,
+	_ cmdOpts,
+	out io.Writer,
+) (int, error) {
+	data, err := json.MarshalIndent(version.ConfigSchema(), "", "  ")
+	if err != nil {
+		return 1, err
+	}
+	_, err = fmt.Fprintln(out, string(data))
+	if err != nil {
+		return 1, err
+	}
+	return 0, nil
+}
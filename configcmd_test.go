@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigActions(t *testing.T) {
+	dir := newProject(t, `DefaultVersion = "1.5.0"`+"\n")
+	tests := []struct {
+		args []string
+		code int
+		err  string
+	}{
+		{[]string{"config", "validate"}, 0, ""},
+		{[]string{"config"}, 1, "missing action, expected one of: [explain schema show validate]"},
+		{
+			[]string{"config", "--action", "foo"}, 1,
+			`unknown action "foo", expected one of: [explain schema show validate]`,
+		},
+	}
+	for _, tt := range tests {
+		code, err := routeCmd(tt.args, dir, nil, io.Discard, io.Discard)
+		if code != tt.code {
+			t.Errorf("%q: exit code %d, want %d", tt.args, code, tt.code)
+		}
+		if (err == nil) != (tt.err == "") || err != nil && err.Error() != tt.err {
+			t.Errorf("%q: error %v, want %q", tt.args, err, tt.err)
+		}
+	}
+}
+
+func TestConfigShowAndExplain(t *testing.T) {
+	dir := newProject(t, `DefaultVersion = "1.5.0"
+ReadOnlyFiles = ["package.json"]
+
+[Sources.Pkg]
+Type = "json"
+Path = "package.json"
+KeyPath = ["version"]
+`)
+	err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"version": "1.2.0"}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := filepath.Join(dir, "version.toml")
+	tests := []struct {
+		args []string
+		out  string
+	}{
+		{
+			[]string{"config", "show"},
+			"# config: " + config + `
+DefaultVersion = "1.5.0"
+Scheme = "semver"
+Strict = false
+ReadOnlyFiles = ["package.json"]
+
+[Sources.Pkg]
+Type = "json"
+VPrefix = "auto"
+Path = "package.json"
+KeyPath = ["version"]
+`,
+		},
+		{
+			[]string{"config", "show", "--json"},
+			`{
+  "DefaultVersion": "1.5.0",
+  "ReadOnlyFiles": [
+    "package.json"
+  ],
+  "Scheme": "semver",
+  "Sources": {
+    "Pkg": {
+      "KeyPath": [
+        "version"
+      ],
+      "Path": "package.json",
+      "Type": "json",
+      "VPrefix": "auto"
+    }
+  },
+  "Strict": false
+}
+`,
+		},
+		{
+			[]string{"config", "explain"},
+			"config: " + config + "\nroot: " + dir + `
+scheme: semver
+default version: 1.5.0
+strict: false
+ignored files: none
+read-only files: package.json
+
+Pkg: json
+  KeyPath = ["version"]
+  Path = "package.json"
+  VPrefix = "auto"
+  files:
+    package.json (read-only)
+`,
+		},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		code, err := routeCmd(tt.args, dir, nil, &out, io.Discard)
+		if code != 0 || err != nil {
+			t.Errorf("%q: exit code %d, %v", tt.args, code, err)
+		}
+		if out.String() != tt.out {
+			t.Errorf("%q: output:\n%s\nwant:\n%s", tt.args, out.String(), tt.out)
+		}
+	}
+	// Without config default sources are explained
+	if err := os.Remove(config); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	code, err := routeCmd([]string{"config", "explain"}, dir, nil, &out, io.Discard)
+	if code != 0 || err != nil || !strings.HasPrefix(out.String(), "config: none, using default sources\n") {
+		t.Errorf("explain without config: exit code %d, %v, output %q", code, err, out.String())
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/asciimoth/rewrite"
+	"github.com/asciimoth/version/pkg/version"
+)
+
+// Range of lines, 1-based, inclusive.
+type lineRange struct {
+	start, end int
+}
+
+// File -> covered line ranges, nil ranges means whole file.
+type coverage map[string][]lineRange
+
+func (c coverage) covers(file string, line int) bool {
+	ranges, ok := c[file]
+	if !ok {
+		return false
+	}
+	if ranges == nil {
+		return true
+	}
+	return slices.ContainsFunc(ranges, func(r lineRange) bool {
+		return r.start <= line && line <= r.end
+	})
+}
+
+func (c coverage) add(file string, ranges []lineRange) {
+	prev, ok := c[file]
+	switch {
+	case ok && prev == nil:
+	case ranges == nil:
+		c[file] = nil
+	default:
+		c[file] = append(slices.Clone(ranges), prev...)
+	}
+}
+
+// `find` subcommand handler.
+func cmdFind(
+	group version.SourceGroup,
+	_ []string,
+	srcs []string,
+	ver []semver.Version,
+	opts cmdOpts,
+	out io.Writer,
+) (int, error) {
+	if len(srcs) == 0 && len(ver) == 0 {
+		srcs = slices.Sorted(maps.Keys(group.Sources))
+	}
+	current, err := group.GetMax(srcs, ver)
+	if err != nil {
+		return 1, err
+	}
+	fs := group.FS()
+	cov, err := sourcesCoverage(fs, group.Sources)
+	if err != nil {
+		return 1, err
+	}
+	files, err := walkFS(fs)
+	if err != nil {
+		return 1, err
+	}
+	skipped := slices.Concat(configFiles, historyFiles)
+	files = slices.DeleteFunc(files, func(f string) bool {
+		return matchesAny(f, skipped)
+	})
+	v := group.Format(version.TrimVPrefix(current))
+	short := fmt.Sprintf("%d.%d", current.Major(), current.Minor())
+	variants := []string{v, "v" + v, short, "v" + short}
+	group.Log(fmt.Sprintf(
+		"looking for %s in files not covered by sources...", v,
+	))
+	found, err := findOccurrences(fs, files, variants)
+	if err != nil {
+		return 1, err
+	}
+	stray := 0
+	for _, occ := range found {
+		if cov.covers(occ.file, occ.line) {
+			group.Trace(fmt.Sprintf(
+				"  %s:%d: covered by sources", occ.file, occ.line,
+			))
+			continue
+		}
+		stray++
+		_, err := fmt.Fprintf(
+			out, "%s:%d:%d: %s\n", occ.file, occ.line, occ.col, occ.text,
+		)
+		if err != nil {
+			return 1, err
+		}
+	}
+	if stray == 0 {
+		group.Log("  nothing found")
+		return 0, nil
+	}
+	group.Log(fmt.Sprintf("  %d occurrences found", stray))
+	if opts.has("fail") {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Returns files and lines managed by enabled sources.
+func sourcesCoverage(fs version.FS, srcs map[version.Name]version.SourceWithMeta) (coverage, error) {
+	cov := coverage{}
+	for _, name := range slices.Sorted(maps.Keys(srcs)) {
+		swm := srcs[name]
+		if swm.Disabled || swm.Source == nil {
+			continue
+		}
+		files, err := sourceFiles(fs, swm.Source)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		for _, file := range files {
+			ranges, err := sourceLines(fs, swm.Source, file)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			cov.add(file, ranges)
+		}
+	}
+	return cov, nil
+}
+
+// Returns files managed by source, none for sources without files.
+func sourceFiles(fs version.FS, src version.Source) ([]string, error) {
+	fsrc, ok := src.(version.FileSource)
+	if !ok {
+		return []string{}, nil
+	}
+	return fsrc.Files(fs)
+}
+
+// Returns lines of file managed by source, nil means whole file.
+// Only regexp sources are narrowed down to lines matched by their outer
+// regexp as they usually target a single line of arbitrary text file.
+func sourceLines(fs version.FS, src version.Source, file string) ([]lineRange, error) {
+	rs, ok := src.(*version.RegexpSource)
+	if !ok || len(rs.KeyPath) == 0 {
+		return nil, nil
+	}
+	re, err := regexp.Compile(rs.KeyPath[0])
+	if err != nil {
+		return nil, err
+	}
+	data, err := rewrite.Read(fs, file)
+	if err != nil {
+		return nil, err
+	}
+	ranges := []lineRange{}
+	for _, m := range re.FindAllIndex(data, -1) {
+		start := bytes.Count(data[:m[0]], []byte("\n")) + 1
+		end := start + bytes.Count(data[m[0]:m[1]], []byte("\n"))
+		ranges = append(ranges, lineRange{start, end})
+	}
+	return ranges, nil
+}
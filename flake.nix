@@ -34,13 +34,21 @@
         version = builtins.readFile ./VERSION;
         src = ./.;
         modules = ./gomod2nix.toml;
-        nativeBuildInputs = [ pkgs.gzip ];
+        nativeBuildInputs = [ pkgs.gzip pkgs.installShellFiles ];
         # after the default install, put the man into $out/share/man/man1/
         postInstall = ''
           mkdir -p $out/share/man/man1
           if [ -f ${./man/version.1} ]; then
             gzip -n -c -k ${./man/version.1} > $out/share/man/man1/version.1.gz
           fi
+        ''
+        # Completions are generated by built binary, so not when cross-compiling
+        + pkgs.lib.optionalString
+          (pkgs.stdenv.buildPlatform.canExecute pkgs.stdenv.hostPlatform) ''
+          installShellCompletion --cmd version \
+            --bash <($out/bin/version completion bash) \
+            --zsh <($out/bin/version completion zsh) \
+            --fish <($out/bin/version completion fish)
         '';
       };
 
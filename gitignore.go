@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/asciimoth/rewrite"
+	"github.com/asciimoth/version/pkg/version"
+)
+
+// Single .gitignore pattern.
+type ignoreRule struct {
+	dir      string // dir of .gitignore file, "" for root
+	re       *regexp.Regexp
+	negate   bool
+	dirOnly  bool
+	anchored bool // pattern is matched against path, not base name
+}
+
+// Ordered .gitignore rules, the last matching rule wins.
+type gitignore []ignoreRule
+
+// Returns rules extended with .gitignore file from dir if it exists.
+func (g gitignore) load(fs version.FS, dir string) gitignore {
+	data, err := rewrite.Read(fs, path.Join(dir, ".gitignore"))
+	if err != nil {
+		return g
+	}
+	rules := slices.Clip(g)
+	for line := range bytes.Lines(data) {
+		rule, ok := parseIgnoreRule(
+			strings.TrimRight(string(line), "\r\n"),
+			dir,
+		)
+		if ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// Reports whether file or dir is ignored.
+func (g gitignore) ignored(file string, isDir bool) bool {
+	ignored := false
+	for _, rule := range g {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		rel := file
+		if rule.dir != "" {
+			if !strings.HasPrefix(file, rule.dir+"/") {
+				continue
+			}
+			rel = file[len(rule.dir)+1:]
+		}
+		if !rule.anchored {
+			rel = path.Base(rel)
+		}
+		if rule.re.MatchString(rel) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+func parseIgnoreRule(line, dir string) (ignoreRule, bool) {
+	// Trailing spaces are ignored unless escaped
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+	rule := ignoreRule{dir: dir}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+	re, err := regexp.Compile("^" + globToRegexp(line) + "$")
+	if err != nil {
+		return ignoreRule{}, false
+	}
+	rule.re = re
+	return rule, true
+}
+
+// Converts gitignore glob to regexp, `**` matches any number of dirs.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
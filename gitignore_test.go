@@ -0,0 +1,89 @@
+package main
+
+import "testing"
+
+func TestGitignore(t *testing.T) {
+	var g gitignore
+	for _, r := range []struct{ line, dir string }{
+		{"# comment", ""},
+		{"", ""},
+		{"*.log", ""},
+		{"!keep.log", ""},
+		{"/build", ""},
+		{"cache/", ""},
+		{"docs/**/*.tmp", ""},
+		{"\\#notes", ""},
+		{"trailing   ", ""},
+		{"space\\ ", ""},
+		{"file[0-9].txt", ""},
+		{"draft[!s]", ""},
+		{"?.bak", ""},
+		{"local.md", "pkg"},
+		{"/gen", "pkg"},
+	} {
+		if rule, ok := parseIgnoreRule(r.line, r.dir); ok {
+			g = append(g, rule)
+		}
+	}
+	tests := []struct {
+		file    string
+		isDir   bool
+		ignored bool
+	}{
+		{"debug.log", false, true},
+		{"sub/dir/debug.log", false, true},
+		{"keep.log", false, false},
+		{"sub/keep.log", false, false},
+		{"build", true, true},
+		{"sub/build", true, false},
+		{"cache", true, true},
+		{"sub/cache", true, true},
+		{"cache", false, false},
+		{"docs/a.tmp", false, true},
+		{"docs/x/y/a.tmp", false, true},
+		{"a.tmp", false, false},
+		{"#notes", false, true},
+		{"trailing", false, true},
+		{"space ", false, true},
+		{"space", false, false},
+		{"file1.txt", false, true},
+		{"filex.txt", false, false},
+		{"drafts", false, false},
+		{"draftx", false, true},
+		{"a.bak", false, true},
+		{"ab.bak", false, false},
+		// Rules of nested .gitignore are relative to its dir
+		{"pkg/local.md", false, true},
+		{"pkg/sub/local.md", false, true},
+		{"local.md", false, false},
+		{"pkg/gen", true, true},
+		{"pkg/sub/gen", true, false},
+		{"gen", true, false},
+		{"README.md", false, false},
+	}
+	for _, tt := range tests {
+		if got := g.ignored(tt.file, tt.isDir); got != tt.ignored {
+			t.Errorf("ignored(%q, %t) = %t, want %t", tt.file, tt.isDir, got, tt.ignored)
+		}
+	}
+}
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		glob string
+		re   string
+	}{
+		{"*.go", `[^/]*\.go`},
+		{"a/**/b", `a/(?:.*/)?b`},
+		{"a/**", `a/.*`},
+		{"?x", `[^/]x`},
+		{"[!a]", `[^a]`},
+		{"[abc", `\[abc`},
+		{`\*`, `\*`},
+	}
+	for _, tt := range tests {
+		if got := globToRegexp(tt.glob); got != tt.re {
+			t.Errorf("globToRegexp(%q) = %q, want %q", tt.glob, got, tt.re)
+		}
+	}
+}
@@ -7,12 +7,12 @@ require (
 	github.com/asciimoth/colorit v0.1.0
 	github.com/asciimoth/inplace v0.2.0
 	github.com/asciimoth/rewrite v0.1.1
+	github.com/creachadair/tomledit v0.0.29
+	github.com/goccy/go-yaml v1.18.0
 	github.com/pelletier/go-toml v1.9.5
 )
 
 require (
-	github.com/creachadair/tomledit v0.0.29 // indirect
-	github.com/goccy/go-yaml v1.18.0 // indirect
 	github.com/pelletier/go-toml/v2 v2.2.4 // indirect
 	github.com/tailscale/hujson v0.0.0-20250605163823-992244df8c5a // indirect
 	golang.org/x/sys v0.36.0 // indirect
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/asciimoth/rewrite"
+	"github.com/asciimoth/version/pkg/version"
+	"github.com/creachadair/tomledit"
+	"github.com/creachadair/tomledit/parser"
+)
+
+// Version-like substring used in generated regexp sources.
+const semverPattern = releasePattern + `(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?`
+
+// Version without prerelease and build, used when version is followed by
+// text prerelease pattern would swallow, e.g. "-blue" of badge URL.
+const releasePattern = `v?\d+\.\d+\.\d+`
+
+// Max length of line context used in generated regexp sources.
+const maxContextLen = 24
+
+// Config files never proposed as sources.
+var configFiles = []string{
+	"version.toml", ".version.toml", "version.yaml", "version.yml",
+	"version.json", "go.sum", "*.lock", "*-lock.json", "*-lock.yaml",
+}
+
+// `init` subcommand handler.
+func cmdInit(
+	group version.SourceGroup,
+	_ []string,
+	_ []string,
+	_ []semver.Version,
+	opts cmdOpts,
+	out io.Writer,
+) (int, error) {
+	fs := group.FS()
+	pyproject := opts.has("pyproject")
+	file := "version.toml"
+	if pyproject {
+		file = "pyproject.toml"
+	}
+	if !opts.has("force") && !opts.has("dry-run") {
+		exists, err := configExists(fs, file, pyproject)
+		if err != nil {
+			return 1, err
+		}
+		if exists {
+			return 1, fmt.Errorf(
+				"%s already contains config, use --force to overwrite",
+				file,
+			)
+		}
+	}
+	current, srcs, err := proposeSources(group)
+	if err != nil {
+		return 1, err
+	}
+	if current == nil {
+		group.Log("no current version found, only manifests are proposed")
+	}
+	table := "Sources"
+	if pyproject {
+		table = "tool.version.Sources"
+	}
+	config, err := version.EncodeSourcesTOML(srcs, table)
+	if err != nil {
+		return 1, err
+	}
+	if opts.has("dry-run") {
+		_, err := fmt.Fprint(out, config)
+		if err != nil {
+			return 1, err
+		}
+		return 0, nil
+	}
+	data := []byte(config)
+	if pyproject {
+		data, err = replacePyProjectConfig(fs, config)
+		if err != nil {
+			return 1, err
+		}
+	}
+	err = rewrite.Write(fs, file, data)
+	if err != nil {
+		return 1, err
+	}
+	group.Log(fmt.Sprintf("%s written with %d sources", file, len(srcs)))
+	return 0, nil
+}
+
+func configExists(fs version.FS, file string, pyproject bool) (bool, error) {
+	data, err := rewrite.Read(fs, file)
+	if err != nil {
+		return false, nil //nolint:nilerr
+	}
+	if !pyproject {
+		return true, nil
+	}
+	doc, err := tomledit.Parse(bytes.NewReader(data))
+	if err != nil {
+		return false, err
+	}
+	for _, sec := range doc.Sections {
+		if sec.Heading != nil &&
+			(parser.Key{"tool", "version"}).IsPrefixOf(sec.Name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Detects current version and sources containing it:
+// known manifests first, then any other files mentioning it.
+func proposeSources(group version.SourceGroup) (
+	*semver.Version,
+	map[version.Name]version.SourceWithMeta,
+	error,
+) {
+	fs := group.FS()
+	srcs := map[version.Name]version.SourceWithMeta{}
+	group.Log("detecting current version...")
+	current, err := (&version.GitSource{}).Get(fs)
+	if err != nil {
+		group.Trace(fmt.Sprintf("  git failed with: %s", err))
+	}
+	if current != nil {
+		group.Log("  git tags report version: " + current.String())
+		srcs["Git"] = version.DefaultSources()["Git"]
+	}
+	detected := version.DetectDefaultSources(fs)
+	delete(detected, "Git")
+	versions := map[version.Name]*semver.Version{}
+	for _, name := range slices.Sorted(maps.Keys(detected)) {
+		src := detected[name]
+		v, err := src.Source.Get(group.SourceFS(fs, src))
+		if err != nil {
+			group.Err(fmt.Sprintf("  %s failed with: %s", name, err))
+			continue
+		}
+		if v == nil {
+			continue
+		}
+		versions[name] = v
+		if current == nil || v.GreaterThan(current) {
+			if current != nil {
+				group.Log(fmt.Sprintf(
+					"  %s reports greater version: %s", name, v,
+				))
+			}
+			current = v
+		}
+	}
+	// Files of all detected manifests are covered by their sources
+	covered := slices.Clone(configFiles)
+	covered = append(covered, historyFiles...)
+	for name, v := range versions {
+		covered = append(covered, version.DefaultSourceDetect(name)...)
+		if v.Equal(current) {
+			srcs[name] = detected[name]
+			group.Log(fmt.Sprintf("  %s: %s", name, v))
+			continue
+		}
+		group.Log(fmt.Sprintf("  %s skipped as reporting %s", name, v))
+	}
+	if current == nil {
+		return nil, srcs, nil
+	}
+	group.Log("looking for other files containing " + current.String())
+	files, err := walkFS(fs)
+	if err != nil {
+		return nil, nil, err
+	}
+	files = slices.DeleteFunc(files, func(f string) bool {
+		return matchesAny(f, covered)
+	})
+	found, err := findOccurrences(
+		fs, files, []string{current.String(), "v" + current.String()},
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, occ := range found {
+		src, kp := regexpSourceFor(occ)
+		if slices.ContainsFunc(slices.Collect(maps.Values(srcs)),
+			func(s version.SourceWithMeta) bool {
+				r, ok := s.Source.(*version.RegexpSource)
+				return ok && r.Path == occ.file && slices.Equal(r.KeyPath, kp)
+			}) {
+			continue
+		}
+		name := uniqueName(sourceNameFor(occ.file), srcs)
+		srcs[name] = src
+		group.Log(fmt.Sprintf("  %s:%d: %s", occ.file, occ.line, occ.text))
+	}
+	return current, srcs, nil
+}
+
+// Builds regexp source updating version occurrence with the same context.
+func regexpSourceFor(occ occurrence) (version.SourceWithMeta, []string) {
+	before := occ.text[:occ.col-1]
+	after := occ.text[occ.col-1+len(occ.match):]
+	pattern := semverPattern
+	format := ""
+	// Such source can't keep prerelease, so it is compared only by release
+	if strings.HasPrefix(after, "-") || strings.HasPrefix(after, "+") {
+		pattern = releasePattern
+		format = "{major}.{minor}.{patch}"
+		if strings.HasPrefix(occ.match, "v") {
+			format = "v" + format
+		}
+	}
+	outer := `(?m)^` + regexp.QuoteMeta(before) + pattern
+	if len(before) > maxContextLen {
+		cut := before[len(before)-maxContextLen:]
+		// Don't cut tokens
+		if i := strings.IndexFunc(cut, isDelimiter); i >= 0 {
+			outer = regexp.QuoteMeta(cut[i:]) + pattern
+		}
+	}
+	kp := []string{outer, pattern + `$`}
+	vprefix := version.VPrefixFalse
+	if strings.HasPrefix(occ.match, "v") && format == "" {
+		vprefix = version.VPrefixTrue
+	}
+	return version.SourceWithMeta{
+		VPrefix: vprefix,
+		Format:  format,
+		Source:  &version.RegexpSource{Path: occ.file, KeyPath: kp},
+	}, kp
+}
+
+func isDelimiter(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// Converts file path to CamelCase source name,
+// e.g. "docs/install.md" -> "DocsInstallMd".
+func sourceNameFor(file string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range file {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	name := b.String()
+	if !version.IsSourceName(name) {
+		name = "File" + name
+	}
+	return name
+}
+
+func uniqueName(name string, srcs map[version.Name]version.SourceWithMeta) string {
+	if _, ok := srcs[name]; !ok {
+		return name
+	}
+	for i := 2; ; i++ {
+		n := fmt.Sprintf("%s%d", name, i)
+		if _, ok := srcs[n]; !ok {
+			return n
+		}
+	}
+}
+
+// Returns pyproject.toml content with `[tool.version]` tables replaced
+// by config. Other lines stay untouched.
+func replacePyProjectConfig(fs version.FS, config string) ([]byte, error) {
+	data, err := rewrite.Read(fs, "pyproject.toml")
+	if err != nil {
+		return nil, errors.New("pyproject.toml not found")
+	}
+	doc, err := tomledit.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	drop := make([]bool, len(lines))
+	for i, sec := range doc.Sections {
+		if sec.Heading == nil ||
+			!(parser.Key{"tool", "version"}).IsPrefixOf(sec.Name) {
+			continue
+		}
+		end := len(lines)
+		if i+1 < len(doc.Sections) && doc.Sections[i+1].Heading != nil {
+			end = doc.Sections[i+1].Line - 1
+		}
+		for l := sec.Line - 1; l < end; l++ {
+			drop[l] = true
+		}
+	}
+	var b bytes.Buffer
+	for i, line := range lines {
+		if !drop[i] {
+			b.Write(line)
+		}
+	}
+	res := bytes.TrimRight(b.Bytes(), "\n")
+	return append(res, []byte("\n\n"+config)...), nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/asciimoth/inplace/regexp"
+)
+
+func TestRegexpSourceFor(t *testing.T) {
+	tests := []struct {
+		text    string
+		match   string
+		format  string
+		context string
+	}{
+		{
+			text:    `version = "1.4.2"`,
+			match:   "1.4.2",
+			context: `(?m)^version = "`,
+		},
+		{
+			text:    "![badge](https://img.shields.io/badge/version-v1.4.2-blue)",
+			match:   "v1.4.2",
+			format:  "v{major}.{minor}.{patch}",
+			context: `\.io/badge/version-`,
+		},
+		{
+			text:    "see https://example.com/releases/download/1.4.2+linux/x",
+			match:   "1.4.2",
+			format:  "{major}.{minor}.{patch}",
+			context: `\.com/releases/download/`,
+		},
+		{
+			text:    "Install with go install example.com/cmd@v1.4.2",
+			match:   "v1.4.2",
+			context: ` example\.com/cmd@`,
+		},
+	}
+	for _, tt := range tests {
+		occ := occurrence{
+			file:  "README.md",
+			line:  1,
+			col:   strings.Index(tt.text, tt.match) + 1,
+			text:  tt.text,
+			match: tt.match,
+		}
+		src, kp := regexpSourceFor(occ)
+		if src.Format != tt.format {
+			t.Errorf("%q: Format = %q, want %q", tt.text, src.Format, tt.format)
+		}
+		if !strings.HasPrefix(kp[0], tt.context) {
+			t.Errorf("%q: context of %q is not %q", tt.text, kp[0], tt.context)
+		}
+		doc, _ := regexp.New([]byte(tt.text))
+		if got := doc.Get(kp); got != tt.match {
+			t.Errorf("%q: read %q, want %q", tt.text, got, tt.match)
+		}
+		if err := doc.Set(kp, "v10.0.0"); err != nil {
+			t.Fatal(err)
+		}
+		if got := doc.Get(kp); got != "v10.0.0" {
+			t.Errorf("%q: read %q after set, want v10.0.0", tt.text, got)
+		}
+	}
+}
@@ -2,28 +2,91 @@
 package main
 
 import (
+	"cmp"
 	_ "embed"
 	"errors"
 	"fmt"
 	"io"
-	"maps"
 	"os"
+	"path/filepath"
 	"slices"
-	"strconv"
 	"strings"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/asciimoth/colorit"
+	"github.com/asciimoth/version/pkg/version"
 )
 
 // Mapping CLI command name -> it's implementation.
 var commands = map[string]func(
-	SourceGroup, []string, []string, []semver.Version, io.Writer,
+	version.SourceGroup, []string, []string, []semver.Version, cmdOpts, io.Writer,
 ) (int, error){
-	"get":  cmdGet,
-	"set":  cmdSet,
-	"bump": cmdBump,
-	"max":  cmdMax,
+	"get":       cmdGet,
+	"set":       cmdSet,
+	"bump":      cmdBump,
+	"max":       cmdMax,
+	"min":       cmdMin,
+	"sort":      cmdSort,
+	"init":      cmdInit,
+	"find":      cmdFind,
+	"satisfies": cmdSatisfies,
+	"compare":   cmdCompare,
+	"sync":      cmdSync,
+	"config":    cmdConfig,
+}
+
+// Mapping CLI command name -> it's own long flags.
+// Flags ending with "=" take a value, "-" means that command reads stdin.
+var commandFlags = map[string][]string{
+	"max":       filterFlags,
+	"min":       filterFlags,
+	"sort":      slices.Concat(filterFlags, []string{"desc", "unique"}),
+	"init":      {"force", "pyproject", "dry-run"},
+	"find":      {"fail"},
+	"satisfies": {"constraint="},
+	"compare":   {"op="},
+	"sync":      {"dry-run"},
+	"config":    {"action=", "json"},
+}
+
+// Long flags accepted by every command.
+var globalFlags = []string{
+	"help", "strict", "verbose", "quiet",
+	"config=", "root=", "set=", "source=", "group=", "format=",
+}
+
+// Value flags that may be provided several times, all values are kept.
+var repeatedFlags = []string{"set", "source", "group"}
+
+// Mapping single letter flag -> long flag.
+var shortFlags = map[string]string{
+	"h": "help",
+	"s": "strict",
+	"v": "verbose",
+	"q": "quiet",
+}
+
+// Subcommand specific args.
+type cmdOpts struct {
+	// Provided flag -> value, empty for flags without value.
+	// The last value is kept for repeated flags
+	flags map[string]string
+	// Repeated flag -> all values in provided order
+	lists map[string][]string
+	// Source names and version literals in provided order
+	operands []string
+	stdin    io.Reader
+	// Path of loaded config, empty if default sources are used
+	config string
+	// Project root
+	root string
+	// Config loading error, only `config` command runs despite it
+	loadErr error
+}
+
+func (o cmdOpts) has(flag string) bool {
+	_, ok := o.flags[flag]
+	return ok
 }
 
 // List of SemVer version parts.
@@ -41,6 +104,24 @@ var (
 	helpBump string
 	//go:embed helps/max.txt
 	helpMax string
+	//go:embed helps/init.txt
+	helpInit string
+	//go:embed helps/find.txt
+	helpFind string
+	//go:embed helps/satisfies.txt
+	helpSatisfies string
+	//go:embed helps/compare.txt
+	helpCompare string
+	//go:embed helps/min.txt
+	helpMin string
+	//go:embed helps/sort.txt
+	helpSort string
+	//go:embed helps/sync.txt
+	helpSync string
+	//go:embed helps/config.txt
+	helpConfig string
+	//go:embed helps/completion.txt
+	helpCompletion string
 )
 
 // Function to parse CLI args:
@@ -50,7 +131,15 @@ var (
 // - `elements` - list of uinique provided SemVer parts names
 // - `srcs` - list of names of sources
 // - `vs` - list of provided SemVer version constants e.g. {"1.2.3", "6.5.4"}
+// - `opts` - subcommand specific flags and operands
 // - `err` - error.
+//
+// Flags may be written as `--name value`, `--name=value` or `-x` for
+// single letter ones, which may be combined like `-vs`.
+// Positional args are classified by their form: version parts names,
+// CamelCase source names, versions and subcommand shorthands like
+// `compare` operators. Every arg after `--` is a version if it parses as
+// one and a source name otherwise.
 func parseCmd(args []string) (
 	cmd string,
 	help bool,
@@ -58,50 +147,205 @@ func parseCmd(args []string) (
 	elems []string,
 	srcs []string,
 	vs []semver.Version,
+	opts cmdOpts,
 	err error,
 ) {
 	srcs = []string{}
 	elems = []string{}
 	vs = []semver.Version{}
-	if len(args) > 0 {
-		if slices.Contains(slices.Collect(maps.Keys(commands)), args[0]) {
-			cmd = args[0]
-			args = args[1:]
-		}
+	opts = cmdOpts{
+		flags:    map[string]string{},
+		lists:    map[string][]string{},
+		operands: []string{},
 	}
-	for _, arg := range args {
-		// Normalised arg
-		narg := strings.ToLower(strings.TrimLeft(strings.TrimSpace(arg), "-"))
-		if narg == "help" || narg == "h" {
-			help = true
-			return
-		}
-		if narg == "strict" || narg == "s" {
-			strict = true
+	cmd, args = splitCommand(args)
+	if wantsHelp(args) {
+		help = true
+		return
+	}
+	p := argParser{cmd: cmd, flags: slices.Concat(globalFlags, commandFlags[cmd])}
+	addSource := func(name string) {
+		srcs = append(srcs, name)
+		opts.operands = append(opts.operands, name)
+	}
+	positional := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if positional {
+			if v, e := semver.NewVersion(arg); e == nil {
+				vs = append(vs, *v)
+				opts.operands = append(opts.operands, arg)
+			} else {
+				addSource(arg)
+			}
 			continue
 		}
-		if slices.Contains(elements, narg) && !slices.Contains(elems, narg) {
-			elems = append(elems, narg)
+		if arg == "--" {
+			positional = true
 			continue
 		}
-		if startsWithCapital(arg) || arg == "none" {
-			srcs = append(srcs, arg)
+		if arg == "-" && slices.Contains(p.flags, "-") {
+			opts.operands = append(opts.operands, arg)
 			continue
 		}
-		v, e := semver.NewVersion(narg)
-		if e == nil {
-			vs = append(vs, *v)
+		if strings.HasPrefix(arg, "-") && len(arg) > 1 {
+			var names []string
+			names, i, err = p.flag(args, i, opts)
+			if err != nil {
+				return
+			}
+			// Unknown flags named like version parts are shorthands too
+			for _, name := range names {
+				if slices.Contains(elements, name) && !slices.Contains(elems, name) {
+					elems = append(elems, name)
+				}
+			}
 			continue
 		}
-		err = fmt.Errorf("unknown arg type \"%s\"", arg)
+		// Normalised arg
+		narg := strings.ToLower(strings.TrimSpace(arg))
+		switch {
+		case narg == "strict" || narg == "s":
+			opts.flags["strict"] = ""
+		case slices.Contains(elements, narg):
+			if !slices.Contains(elems, narg) {
+				elems = append(elems, narg)
+			}
+		case version.IsSourceName(arg) || arg == "none":
+			addSource(arg)
+		default:
+			v, e := semver.NewVersion(narg)
+			if e == nil {
+				vs = append(vs, *v)
+				opts.operands = append(opts.operands, narg)
+				continue
+			}
+			ok, e := parsePositionalFlag(cmd, arg, opts.flags)
+			if e != nil {
+				err = p.errorf("%s", e)
+				return
+			}
+			if !ok {
+				err = p.errorf("unknown argument %q", arg)
+				return
+			}
+		}
+	}
+	for _, name := range opts.lists["source"] {
+		addSource(name)
+	}
+	if opts.has("verbose") && opts.has("quiet") {
+		err = p.errorf("flags --verbose and --quiet can't be used together")
 		return
 	}
+	help = opts.has("help")
+	strict = opts.has("strict")
 	if cmd == "" {
 		cmd = "get"
 	}
 	return
 }
 
+// Reports whether help is requested by any arg before `--` like `-h`,
+// `--help` or just `help`.
+func wantsHelp(args []string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			return false
+		}
+		narg := strings.ToLower(strings.TrimLeft(strings.TrimSpace(arg), "-"))
+		if narg == "help" || narg == "h" {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns subcommand name and args without it.
+// Subcommand is the first arg that is neither a global flag nor its value.
+func splitCommand(args []string) (string, []string) {
+	for pos := 0; pos < len(args) && args[pos] != "--"; pos++ {
+		arg := args[pos]
+		if _, ok := commands[arg]; ok {
+			return arg, slices.Delete(slices.Clone(args), pos, pos+1)
+		}
+		if !strings.HasPrefix(arg, "-") {
+			break
+		}
+		name := strings.TrimLeft(arg, "-")
+		if slices.Contains(globalFlags, name+"=") {
+			pos++
+		}
+	}
+	return "", args
+}
+
+// Parses flags of subcommand.
+type argParser struct {
+	cmd string
+	// Accepted flags, see commandFlags
+	flags []string
+}
+
+// Returns error mentioning subcommand and its help.
+func (p argParser) errorf(format string, args ...any) error {
+	cmd := cmp.Or(p.cmd, "get")
+	return fmt.Errorf(
+		"%s: %s, see `version %s --help`", cmd, fmt.Sprintf(format, args...), cmd,
+	)
+}
+
+// Parses flag at args[i] storing it in opts, returns index of its last
+// arg (value may be the next one).
+// Names of unknown flags are returned for positional shorthands
+// like `--major`, other unknown flags are errors.
+func (p argParser) flag(
+	args []string,
+	i int,
+	opts cmdOpts,
+) ([]string, int, error) {
+	arg := args[i]
+	name, val, hasVal := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+	name = strings.ToLower(name)
+	if !strings.HasPrefix(arg, "--") && !hasVal {
+		// Single letter flags, possibly combined
+		letters := strings.Split(name, "")
+		if slices.IndexFunc(letters, func(l string) bool {
+			_, ok := shortFlags[l]
+			return !ok
+		}) < 0 {
+			for _, l := range letters {
+				opts.flags[shortFlags[l]] = ""
+			}
+			return nil, i, nil
+		}
+	}
+	switch {
+	case slices.Contains(p.flags, name):
+		if hasVal {
+			return nil, i, p.errorf("flag --%s takes no value", name)
+		}
+		opts.flags[name] = ""
+	case slices.Contains(p.flags, name+"="):
+		if !hasVal {
+			if i+1 >= len(args) {
+				return nil, i, p.errorf("flag --%s requires a value", name)
+			}
+			i++
+			val = args[i]
+		}
+		opts.flags[name] = val
+		if slices.Contains(repeatedFlags, name) {
+			opts.lists[name] = append(opts.lists[name], val)
+		}
+	case slices.Contains(elements, name) && !hasVal:
+		return []string{name}, i, nil
+	default:
+		return nil, i, p.errorf("unknown flag %s", arg)
+	}
+	return nil, i, nil
+}
+
 // `--help` flag handler.
 func showHelp(cmd string, out io.Writer) error {
 	text := helpMain
@@ -114,16 +358,63 @@ func showHelp(cmd string, out io.Writer) error {
 		text = helpBump
 	case "max":
 		text = helpMax
+	case "init":
+		text = helpInit
+	case "find":
+		text = helpFind
+	case "satisfies":
+		text = helpSatisfies
+	case "compare":
+		text = helpCompare
+	case "min":
+		text = helpMin
+	case "sort":
+		text = helpSort
+	case "sync":
+		text = helpSync
+	case "config":
+		text = helpConfig
+	case "completion":
+		text = helpCompletion
 	}
 	return colorit.HighlightTo(text, "help", out)
 }
 
+// Prints version formatted with group scheme or `--format` template.
+func printVersion(
+	group version.SourceGroup,
+	v *semver.Version,
+	opts cmdOpts,
+	out io.Writer,
+) error {
+	s, err := formatVersion(group, v, opts)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(out, s)
+	return err
+}
+
+// Formats version with group scheme or `--format` template, which is
+// checked before command runs.
+func formatVersion(
+	group version.SourceGroup,
+	v *semver.Version,
+	opts cmdOpts,
+) (string, error) {
+	if format, ok := opts.flags["format"]; ok {
+		return group.FormatTemplate(v, format)
+	}
+	return group.Format(v), nil
+}
+
 // `get` subcomamnd handler.
 func cmdGet(
-	group SourceGroup,
+	group version.SourceGroup,
 	elems []string,
 	srcs []string,
 	ver []semver.Version,
+	opts cmdOpts,
 	out io.Writer,
 ) (int, error) {
 	if len(ver) > 1 {
@@ -139,20 +430,25 @@ func cmdGet(
 		return 1, err
 	}
 	if len(elems) < 1 {
-		_, err := fmt.Fprintln(out, verToString(vers))
+		err := printVersion(group, vers, opts, out)
 		if err != nil {
 			return 1, err
 		}
 		return 0, nil
 	}
 	for _, elem := range elems {
+		var part uint64
 		switch elem {
 		case "major":
-			group.Log(strconv.FormatUint(vers.Major(), 10))
+			part = vers.Major()
 		case "minor":
-			group.Log(strconv.FormatUint(vers.Minor(), 10))
+			part = vers.Minor()
 		case "patch":
-			group.Log(strconv.FormatUint(vers.Patch(), 10))
+			part = vers.Patch()
+		}
+		_, err := fmt.Fprintln(out, part)
+		if err != nil {
+			return 1, err
 		}
 	}
 	return 0, nil
@@ -160,10 +456,11 @@ func cmdGet(
 
 // `bump` subcomamnd handler.
 func cmdBump(
-	group SourceGroup,
+	group version.SourceGroup,
 	elems []string,
 	srcs []string,
 	ver []semver.Version,
+	opts cmdOpts,
 	out io.Writer,
 ) (int, error) {
 	if len(ver) > 1 {
@@ -175,29 +472,24 @@ func cmdBump(
 		group.DefaultVersion = ver[0].Original()
 	}
 	if len(elems) < 1 {
-		elems = []string{"minor"}
+		// Scheme default, minor for SemVer
+		elems = []string{""}
 	}
 	vers, err := group.Get(srcs)
 	if err != nil {
 		return 1, err
 	}
 	for _, elem := range elems {
-		nv := *vers
-		switch elem {
-		case "major":
-			nv = vers.IncMajor()
-		case "minor":
-			nv = vers.IncMinor()
-		case "patch":
-			nv = vers.IncPatch()
+		vers, err = group.VersionScheme().Bump(vers, elem)
+		if err != nil {
+			return 1, err
 		}
-		vers = &nv
 	}
 	err = group.Set(*vers, srcs)
 	if err != nil {
 		return 1, err
 	}
-	_, err = fmt.Fprintln(out, verToString(vers))
+	err = printVersion(group, vers, opts, out)
 	if err != nil {
 		return 1, err
 	}
@@ -206,10 +498,11 @@ func cmdBump(
 
 // `set` subcomamnd handler.
 func cmdSet(
-	group SourceGroup,
+	group version.SourceGroup,
 	_ []string,
 	srcs []string,
 	ver []semver.Version,
+	_ cmdOpts,
 	_ io.Writer,
 ) (int, error) {
 	if len(ver) != 1 {
@@ -224,53 +517,181 @@ func cmdSet(
 
 // `max` subcomamnd handler.
 func cmdMax(
-	group SourceGroup,
+	group version.SourceGroup,
 	_ []string,
 	srcs []string,
 	ver []semver.Version,
-	_ io.Writer,
+	opts cmdOpts,
+	out io.Writer,
 ) (int, error) {
-	v, err := group.GetMax(srcs, ver)
+	v, err := extremum(group, srcs, ver, opts, false)
+	if err != nil {
+		return 1, err
+	}
+	err = printVersion(group, v, opts, out)
 	if err != nil {
 		return 1, err
 	}
-	group.Log(verToString(v))
 	return 0, nil
 }
 
+// Returns project root and config path (empty if there is no config):
+//   - with --config (or VERSION_CONFIG env) config is used as is and root is
+//     its directory unless --root is provided
+//   - with --root config is looked for only in root
+//   - otherwise config is looked for in dir and its parents up to the
+//     repository root, project root is the config directory or dir.
+func locateProject(
+	dir string,
+	opts cmdOpts,
+	envConfig string,
+) (string, string, error) {
+	root := opts.flags["root"]
+	config := cmp.Or(opts.flags["config"], envConfig)
+	var err error
+	switch {
+	case config != "":
+		config, err = filepath.Abs(config)
+		if err != nil {
+			return "", "", err
+		}
+		root = cmp.Or(root, filepath.Dir(config))
+	case root != "":
+		config, err = version.ConfigInDir(root)
+	default:
+		root = dir
+		config, err = version.FindConfig(dir)
+		if config != "" {
+			root = filepath.Dir(config)
+			break
+		}
+		// Default sources are looked up from repository root
+		if repo, e := version.FindRepoRoot(dir); repo != "" {
+			root = repo
+		} else if e != nil {
+			err = e
+		}
+	}
+	if err != nil {
+		return "", "", err
+	}
+	root, err = filepath.Abs(root)
+	return root, config, err
+}
+
+// Returns exit code of errors preventing command from running.
+// Commands reporting result with exit code use 2, as 1 is "false" for them.
+func errorCode(cmd string) int {
+	if cmd == "satisfies" || cmd == "compare" {
+		return 2
+	}
+	return 1
+}
+
 // Function that select and call sultable subcommand handler.
-func routeCmd(args []string, fs FS, sout, serr io.Writer) (int, error) {
-	log := func(s string) { _, _ = fmt.Fprintln(serr, s) }
-	cmd, help, strict, elems, srcs, vs, err := parseCmd(args)
+func routeCmd(
+	args []string,
+	dir string,
+	sin io.Reader,
+	sout, serr io.Writer,
+) (int, error) {
+	errLog := func(s string) { _, _ = fmt.Fprintln(serr, s) }
+	cmd, help, strict, elems, srcs, vs, opts, err := parseCmd(args)
+	// Usage, parse and config errors
+	fail := errorCode(cmd)
 	if err != nil {
-		return 1, err
+		return fail, err
+	}
+	// Trace is shown with --verbose only, --quiet leaves errors only
+	log, trace := errLog, func(_ string) {}
+	switch {
+	case opts.has("verbose"):
+		trace = errLog
+	case opts.has("quiet"):
+		log = trace
 	}
 	if help {
 		err := showHelp(cmd, sout)
 		if err != nil {
-			return 1, err
+			return fail, err
 		}
 		return 0, nil
 	}
-	group, err := GroupFromConfig(fs, log, log, log, strict)
+	// Shell scripts don't depend on project, so it isn't located and loaded.
+	// That makes them available outside of projects and in build sandboxes.
+	if _, ok := completionShells[opts.flags["action"]]; ok && cmd == "completion" {
+		group := version.SourceGroup{Trace: trace, Log: log, Err: errLog}
+		return cmdCompletion(group, elems, srcs, vs, opts, sout)
+	}
+	root, config, err := locateProject(dir, opts, os.Getenv("VERSION_CONFIG"))
 	if err != nil {
-		return 1, err
+		return fail, err
+	}
+	// Tools and git are run from project root too
+	if err := os.Chdir(root); err != nil {
+		return fail, err
+	}
+	r, err := os.OpenRoot(root)
+	if err != nil {
+		return fail, err
+	}
+	defer r.Close()
+	fs := version.FSFromRoot(r)
+	overrides := version.EnvOverrides(os.Environ())
+	for _, set := range opts.lists["set"] {
+		o, err := version.ParseOverride(set, "--set")
+		if err != nil {
+			return fail, err
+		}
+		overrides = append(overrides, o)
+	}
+	var group *version.SourceGroup
+	if config != "" {
+		log("using config " + config)
+		group, err = version.GroupFromConfigFile(
+			fs, config, trace, log, errLog, strict, overrides...,
+		)
+	} else {
+		log("no config found in " + root + ", using default sources")
+		group, err = version.GroupFromConfig(
+			fs, trace, log, errLog, strict, overrides...,
+		)
+	}
+	if err != nil {
+		if cmd != "config" && cmd != "completion" {
+			return fail, err
+		}
+		opts.loadErr = err
+		group = &version.SourceGroup{Trace: trace, Log: log, Err: errLog}
+	}
+	for _, name := range opts.lists["group"] {
+		names, err := group.GroupSources(name)
+		if err != nil {
+			return fail, err
+		}
+		srcs = append(srcs, names...)
+		opts.operands = append(opts.operands, names...)
+	}
+	if format, ok := opts.flags["format"]; ok {
+		if _, err := group.FormatTemplate(semver.New(0, 0, 0, "", ""), format); err != nil {
+			return fail, err
+		}
 	}
 	f, ok := commands[cmd]
 	if ok {
-		return f(*group, elems, srcs, vs, sout)
+		opts.stdin = sin
+		opts.config = config
+		opts.root = root
+		return f(*group, elems, srcs, vs, opts, sout)
 	}
-	return 1, fmt.Errorf("unknown subcommand %s", cmd)
+	return fail, fmt.Errorf("unknown subcommand %s", cmd)
 }
 
 func main() {
-	root, err := os.OpenRoot(".")
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-	}
 	code, err := routeCmd(
 		os.Args[1:],
-		FSFromRoot(root),
+		".",
+		os.Stdin,
 		os.Stdout,
 		os.Stderr,
 	)
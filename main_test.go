@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseCmd(t *testing.T) {
+	tests := []struct {
+		args     []string
+		cmd      string
+		elems    []string
+		srcs     []string
+		vs       []string
+		flags    map[string]string
+		operands []string
+	}{
+		{
+			args: []string{},
+			cmd:  "get",
+		},
+		{
+			args:     []string{"bump", "major", "Git", "1.2.3"},
+			cmd:      "bump",
+			elems:    []string{"major"},
+			srcs:     []string{"Git"},
+			vs:       []string{"1.2.3"},
+			operands: []string{"Git", "1.2.3"},
+		},
+		{
+			// Parts are case insensitive, so source named like part
+			// must follow `--`
+			args:  []string{"bump", "--patch", "Patch", "minor", "patch"},
+			cmd:   "bump",
+			elems: []string{"patch", "minor"},
+		},
+		{
+			args:     []string{"get", "--", "Major", "v2.0.0", "lower"},
+			cmd:      "get",
+			srcs:     []string{"Major", "lower"},
+			vs:       []string{"v2.0.0"},
+			operands: []string{"Major", "v2.0.0", "lower"},
+		},
+		{
+			args:     []string{"get", "--source", "1.2.3", "--source=git", "Npm"},
+			cmd:      "get",
+			srcs:     []string{"Npm", "1.2.3", "git"},
+			flags:    map[string]string{"source": "git"},
+			operands: []string{"Npm", "1.2.3", "git"},
+		},
+		{
+			// Global flags with values before command
+			args:  []string{"--config", "ci.toml", "-vs", "max", "--format={major}"},
+			cmd:   "max",
+			flags: map[string]string{"config": "ci.toml", "verbose": "", "strict": "", "format": "{major}"},
+		},
+		{
+			args:  []string{"STRICT", "-q"},
+			cmd:   "get",
+			flags: map[string]string{"strict": "", "quiet": ""},
+		},
+		{
+			args:     []string{"set", "none", "2.0.0"},
+			cmd:      "set",
+			srcs:     []string{"none"},
+			vs:       []string{"2.0.0"},
+			operands: []string{"none", "2.0.0"},
+		},
+		{
+			args:     []string{"compare", "1.0.0", "lt", "2.0.0"},
+			cmd:      "compare",
+			vs:       []string{"1.0.0", "2.0.0"},
+			flags:    map[string]string{"op": "lt"},
+			operands: []string{"1.0.0", "2.0.0"},
+		},
+		{
+			args:  []string{"satisfies", "--constraint", ">=1.2, <2"},
+			cmd:   "satisfies",
+			flags: map[string]string{"constraint": ">=1.2, <2"},
+		},
+		{
+			args:     []string{"sort", "-", "--desc"},
+			cmd:      "sort",
+			flags:    map[string]string{"desc": ""},
+			operands: []string{"-"},
+		},
+		{
+			args:  []string{"config", "--action", "show", "--json"},
+			cmd:   "config",
+			flags: map[string]string{"action": "show", "json": ""},
+		},
+		{
+			args:  []string{"--set", "Git.Prefix=v", "--set=Npm.Disabled=true", "get"},
+			cmd:   "get",
+			flags: map[string]string{"set": "Npm.Disabled=true"},
+		},
+	}
+	for _, tt := range tests {
+		cmd, help, strict, elems, srcs, vs, opts, err := parseCmd(tt.args)
+		if err != nil {
+			t.Errorf("%q: %s", tt.args, err)
+			continue
+		}
+		if cmd != tt.cmd || help {
+			t.Errorf("%q: cmd = %q, help = %t, want %q", tt.args, cmd, help, tt.cmd)
+		}
+		if _, ok := tt.flags["strict"]; strict != ok {
+			t.Errorf("%q: strict = %t", tt.args, strict)
+		}
+		versions := []string{}
+		for _, v := range vs {
+			versions = append(versions, v.Original())
+		}
+		for _, c := range []struct {
+			name      string
+			got, want []string
+		}{
+			{"elems", elems, tt.elems},
+			{"srcs", srcs, tt.srcs},
+			{"versions", versions, tt.vs},
+			{"operands", opts.operands, tt.operands},
+		} {
+			if !slices.Equal(c.got, c.want) && len(c.got)+len(c.want) > 0 {
+				t.Errorf("%q: %s = %q, want %q", tt.args, c.name, c.got, c.want)
+			}
+		}
+		if !maps.Equal(opts.flags, tt.flags) && len(opts.flags)+len(tt.flags) > 0 {
+			t.Errorf("%q: flags = %v, want %v", tt.args, opts.flags, tt.flags)
+		}
+	}
+}
+
+func TestParseCmdRepeatedFlags(t *testing.T) {
+	args := []string{"--set", "A.Path=a", "get", "--set=B.Path=b", "--group", "Docs", "--group=Ci"}
+	_, _, _, _, _, _, opts, err := parseCmd(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := opts.lists["set"]; !slices.Equal(got, []string{"A.Path=a", "B.Path=b"}) {
+		t.Errorf("--set values = %q", got)
+	}
+	if got := opts.lists["group"]; !slices.Equal(got, []string{"Docs", "Ci"}) {
+		t.Errorf("--group values = %q", got)
+	}
+}
+
+func TestParseCmdHelp(t *testing.T) {
+	tests := []struct {
+		args []string
+		cmd  string
+		help bool
+	}{
+		{[]string{"--help"}, "", true},
+		{[]string{"-h", "bump"}, "bump", true},
+		{[]string{"bump", "help"}, "bump", true},
+		{[]string{"sort", "--bogus", "-H"}, "sort", true},
+		{[]string{"get", "--", "help"}, "get", false},
+	}
+	for _, tt := range tests {
+		cmd, help, _, _, _, _, _, err := parseCmd(tt.args)
+		if err != nil && tt.help {
+			t.Errorf("%q: %s", tt.args, err)
+		}
+		if cmd != tt.cmd || help != tt.help {
+			t.Errorf("%q: cmd = %q, help = %t, want %q, %t",
+				tt.args, cmd, help, tt.cmd, tt.help)
+		}
+	}
+}
+
+func TestParseCmdErrors(t *testing.T) {
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"get", "--bogus"}, "get: unknown flag --bogus, see `version get --help`"},
+		{[]string{"--bogus"}, "get: unknown flag --bogus"},
+		{[]string{"get", "-x"}, "unknown flag -x"},
+		{[]string{"max", "--desc"}, "max: unknown flag --desc"},
+		{[]string{"get", "--strict=yes"}, "flag --strict takes no value"},
+		{[]string{"get", "--format"}, "flag --format requires a value"},
+		{[]string{"get", "-v", "--quiet"}, "--verbose and --quiet can't be used together"},
+		{[]string{"get", "whatever"}, `get: unknown argument "whatever"`},
+		{[]string{"get", "-"}, `get: unknown argument "-"`},
+		{[]string{"satisfies", ">=1.4, <2x"}, "satisfies: invalid constraint"},
+	}
+	for _, tt := range tests {
+		_, _, _, _, _, _, _, err := parseCmd(tt.args)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%q: error %v, want %q", tt.args, err, tt.err)
+		}
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		args []string
+		cmd  string
+		rest []string
+	}{
+		{[]string{"bump", "major"}, "bump", []string{"major"}},
+		{[]string{"--root", "get", "max"}, "max", []string{"--root", "get"}},
+		{[]string{"-v", "sync", "--dry-run"}, "sync", []string{"-v", "--dry-run"}},
+		// Command must come before operands
+		{[]string{"Git", "max"}, "", []string{"Git", "max"}},
+		{[]string{"--", "max"}, "", []string{"--", "max"}},
+	}
+	for _, tt := range tests {
+		cmd, rest := splitCommand(tt.args)
+		if cmd != tt.cmd || !slices.Equal(rest, tt.rest) {
+			t.Errorf("splitCommand(%q) = %q, %q, want %q, %q",
+				tt.args, cmd, rest, tt.cmd, tt.rest)
+		}
+	}
+}
+
+// --set overrides are applied after VERSION_* env ones.
+func TestOverridesFromEnvAndFlags(t *testing.T) {
+	dir := newProject(t, `DefaultVersion = "1.0.0"`+"\n")
+	t.Setenv("VERSION_DEFAULTVERSION", "2.0.0")
+	var out, errs bytes.Buffer
+	code, err := routeCmd(
+		[]string{"get", "-v", "--set", "DefaultVersion=3.0.0"}, dir, nil, &out, &errs,
+	)
+	if code != 0 || err != nil || out.String() != "3.0.0\n" {
+		t.Errorf("get: exit code %d, %v, output %q, want 3.0.0", code, err, out.String())
+	}
+	env := strings.Index(errs.String(), "override DefaultVersion = 2.0.0 (VERSION_DEFAULTVERSION)")
+	set := strings.Index(errs.String(), "override DefaultVersion = 3.0.0 (--set)")
+	if env < 0 || set < env {
+		t.Errorf("overrides are not traced in order:\n%s", errs.String())
+	}
+}
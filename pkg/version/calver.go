@@ -0,0 +1,246 @@
+package version
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// CalVer format tokens, see https://calver.org.
+const (
+	calVerFullYear   = "YYYY"
+	calVerShortYear  = "YY"
+	calVerZeroYear   = "0Y"
+	calVerMonth      = "MM"
+	calVerZeroMonth  = "0M"
+	calVerWeek       = "WW"
+	calVerZeroWeek   = "0W"
+	calVerDay        = "DD"
+	calVerZeroDay    = "0D"
+	calVerMicro      = "MICRO"
+	calVerDefaultFmt = "YYYY.0M.MICRO"
+)
+
+// Tokens in order they are matched in format.
+var calVerTokens = []string{
+	calVerFullYear, calVerShortYear, calVerZeroYear,
+	calVerMonth, calVerZeroMonth, calVerWeek, calVerZeroWeek,
+	calVerDay, calVerZeroDay, calVerMicro,
+}
+
+func init() {
+	RegisterScheme("calver", func() Scheme { return &CalVerScheme{} })
+}
+
+// CalVerScheme handles calendar versions like `2026.10.3` or `26.04.1`.
+// Format consists of up to three tokens separated by `.`, `-` or `_`:
+// YYYY, YY, 0Y, MM, 0M, WW, 0W, DD, 0D and MICRO.
+// Tokens are stored as major, minor and patch of internal version,
+// `-suffix` is kept as prerelease.
+//
+// Bump rolls date tokens to the current date (SOURCE_DATE_EPOCH if set)
+// and resets MICRO, if date is not changed MICRO is incremented.
+// With week tokens years are ISO week-numbering ones.
+// Bumping of specific part is not supported.
+type CalVerScheme struct {
+	Pattern string `toml:"Format"`
+}
+
+func (s *CalVerScheme) tokens() ([]string, []string, error) {
+	format := cmp.Or(s.Pattern, calVerDefaultFmt)
+	tokens := []string{}
+	seps := []string{}
+	for format != "" {
+		found := false
+		for _, token := range calVerTokens {
+			if strings.HasPrefix(format, token) {
+				tokens = append(tokens, token)
+				format = format[len(token):]
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("invalid CalVer format %q", s.Pattern)
+		}
+		if format == "" {
+			break
+		}
+		if !strings.ContainsAny(format[:1], ".-_") {
+			return nil, nil, fmt.Errorf("invalid CalVer format %q", s.Pattern)
+		}
+		seps = append(seps, format[:1])
+		format = format[1:]
+	}
+	if len(tokens) < 1 || len(tokens) > 3 {
+		return nil, nil, fmt.Errorf(
+			"CalVer format %q must have from one to three tokens", s.Pattern,
+		)
+	}
+	return tokens, seps, nil
+}
+
+func (s *CalVerScheme) Parse(str string) (*semver.Version, error) {
+	tokens, seps, err := s.tokens()
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	b.WriteString(`^(v?)`)
+	for i := range tokens {
+		if i > 0 {
+			b.WriteString(regexp.QuoteMeta(seps[i-1]))
+		}
+		b.WriteString(`(\d+)`)
+	}
+	b.WriteString(`(?:-([0-9A-Za-z.-]+))?$`)
+	m := regexp.MustCompile(b.String()).FindStringSubmatch(str)
+	if m == nil {
+		return nil, fmt.Errorf("%q does not match CalVer format %q",
+			str, cmp.Or(s.Pattern, calVerDefaultFmt))
+	}
+	parts := []string{"0", "0", "0"}
+	for i := range tokens {
+		n, err := strconv.ParseUint(m[i+2], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		parts[i] = strconv.FormatUint(n, 10)
+	}
+	text := m[1] + strings.Join(parts, ".")
+	if pre := m[len(tokens)+2]; pre != "" {
+		text += "-" + pre
+	}
+	return semver.NewVersion(text)
+}
+
+func (s *CalVerScheme) Format(v *semver.Version) string {
+	tokens, seps, err := s.tokens()
+	if err != nil {
+		return verToString(v)
+	}
+	parts := []uint64{v.Major(), v.Minor(), v.Patch()}
+	var b strings.Builder
+	if HasVPrefix(v) {
+		b.WriteString("v")
+	}
+	for i, token := range tokens {
+		if i > 0 {
+			b.WriteString(seps[i-1])
+		}
+		if strings.HasPrefix(token, "0") {
+			fmt.Fprintf(&b, "%02d", parts[i])
+			continue
+		}
+		b.WriteString(strconv.FormatUint(parts[i], 10))
+	}
+	if v.Prerelease() != "" {
+		b.WriteString("-" + v.Prerelease())
+	}
+	return b.String()
+}
+
+func (s *CalVerScheme) Bump(
+	v *semver.Version,
+	part string,
+) (*semver.Version, error) {
+	if part != "" {
+		return nil, fmt.Errorf(
+			"can't bump %s of CalVer version, it is bumped by date", part,
+		)
+	}
+	tokens, _, err := s.tokens()
+	if err != nil {
+		return nil, err
+	}
+	weekly := slices.ContainsFunc(tokens, func(token string) bool {
+		return token == calVerWeek || token == calVerZeroWeek
+	})
+	now, err := calVerNow()
+	if err != nil {
+		return nil, err
+	}
+	prev := []uint64{v.Major(), v.Minor(), v.Patch()}
+	parts := []uint64{0, 0, 0}
+	micro := -1
+	for i, token := range tokens {
+		if token == calVerMicro {
+			micro = i
+			continue
+		}
+		parts[i] = calVerDateValue(token, now, weekly)
+	}
+	if micro >= 0 {
+		parts[micro] = prev[micro]
+	}
+	next := semver.New(parts[0], parts[1], parts[2], "", "")
+	cur := semver.New(prev[0], prev[1], prev[2], "", "")
+	switch {
+	case next.GreaterThan(cur):
+		if micro >= 0 {
+			parts[micro] = 0
+		}
+	case v.Prerelease() != "":
+		// Release of current prerelease
+		parts = prev
+	case micro < 0:
+		return nil, errors.New(
+			"version for current date already exists and " +
+				"CalVer format has no MICRO token",
+		)
+	default:
+		// Date is not changed or clock is behind of the latest release
+		parts = prev
+		parts[micro]++
+	}
+	text := fmt.Sprintf("%d.%d.%d", parts[0], parts[1], parts[2])
+	if HasVPrefix(v) {
+		text = "v" + text
+	}
+	return semver.NewVersion(text)
+}
+
+// Returns current time or SOURCE_DATE_EPOCH for reproducible builds.
+func calVerNow() (time.Time, error) {
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		return time.Now().UTC(), nil
+	}
+	sec, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH: %w", err)
+	}
+	return time.Unix(sec, 0).UTC(), nil
+}
+
+// Returns value of date token, with weekly set years are ISO ones
+// so e.g. 2027-01-01 is in 2026.53.
+func calVerDateValue(token string, t time.Time, weekly bool) uint64 {
+	year := t.Year()
+	if weekly {
+		year, _ = t.ISOWeek()
+	}
+	switch token {
+	case calVerFullYear:
+		return uint64(year) //nolint:gosec
+	case calVerShortYear, calVerZeroYear:
+		// Short years continue after 99, e.g. 2106 is 106
+		return uint64(max(year-2000, 0)) //nolint:gosec
+	case calVerMonth, calVerZeroMonth:
+		return uint64(t.Month())
+	case calVerWeek, calVerZeroWeek:
+		_, week := t.ISOWeek()
+		return uint64(week) //nolint:gosec
+	case calVerDay, calVerZeroDay:
+		return uint64(t.Day()) //nolint:gosec
+	}
+	return 0
+}
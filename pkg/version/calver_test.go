@@ -0,0 +1,109 @@
+package version
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+func TestCalVerRoundTrip(t *testing.T) {
+	tests := []struct {
+		format string
+		text   string
+		semver string
+	}{
+		{"", "2026.04.3", "2026.4.3"},
+		{"YY.0M.MICRO", "26.10.0", "26.10.0"},
+		{"0Y.0M", "06.01", "6.1.0"},
+		{"YY.MM.MICRO", "106.1.2", "106.1.2"},
+		{"YYYY-0W", "2026-05", "2026.5.0"},
+		{"YYYY_0M_0D", "v2026_10_18", "v2026.10.18"},
+		{"YYYY.0M.MICRO", "2026.10.0-rc.1", "2026.10.0-rc.1"},
+	}
+	for _, tt := range tests {
+		s := &CalVerScheme{Pattern: tt.format}
+		v, err := s.Parse(tt.text)
+		if err != nil {
+			t.Errorf("%q: Parse(%q): %s", tt.format, tt.text, err)
+			continue
+		}
+		if v.Original() != tt.semver {
+			t.Errorf("%q: Parse(%q) = %s, want %s", tt.format, tt.text, v.Original(), tt.semver)
+		}
+		if got := s.Format(v); got != tt.text {
+			t.Errorf("%q: Format(%s) = %q, want %q", tt.format, v, got, tt.text)
+		}
+	}
+	for _, format := range []string{"YYYY.0M.MICRO.DD", "YYYY..MM", "YYYY.Q", "YYYYMM"} {
+		if _, err := (&CalVerScheme{Pattern: format}).Parse("2026.10"); err == nil {
+			t.Errorf("Parse with format %q succeeded, want error", format)
+		}
+	}
+	if _, err := (&CalVerScheme{}).Parse("2026.10"); err == nil {
+		t.Error("Parse of text not matching format succeeded")
+	}
+}
+
+func TestCalVerBump(t *testing.T) {
+	tests := []struct {
+		format string
+		date   time.Time
+		prev   string
+		next   string
+	}{
+		{"YY.0M.MICRO", calVerDate(2026, 10, 18), "26.04.3", "26.10.0"},
+		{"YY.0M.MICRO", calVerDate(2026, 10, 18), "26.10.0", "26.10.1"},
+		{"0Y.0M.MICRO", calVerDate(2006, 1, 2), "05.12.4", "06.01.0"},
+		{"YY.MM.MICRO", calVerDate(2106, 3, 1), "99.12.0", "106.3.0"},
+		{"YYYY.0M.MICRO", calVerDate(2026, 10, 18), "2026.10.0-rc.1", "2026.10.0"},
+		{"YYYY.0M.MICRO", calVerDate(2026, 10, 18), "v2026.09.1", "v2026.10.0"},
+		// Clock behind of the latest release
+		{"YYYY.0M.MICRO", calVerDate(2026, 10, 18), "2026.11.2", "2026.11.3"},
+		// ISO week-numbering years
+		{"YYYY.0W", calVerDate(2027, 1, 1), "2026.52", "2026.53"},
+		{"YY.WW.MICRO", calVerDate(2024, 12, 30), "24.52.1", "25.1.0"},
+		{"YYYY.0M.0D", calVerDate(2027, 1, 1), "2026.12.31", "2027.01.01"},
+	}
+	for _, tt := range tests {
+		t.Setenv("SOURCE_DATE_EPOCH", strconv.FormatInt(tt.date.Unix(), 10))
+		s := &CalVerScheme{Pattern: tt.format}
+		v, err := s.Parse(tt.prev)
+		if err != nil {
+			t.Fatalf("%q: Parse(%q): %s", tt.format, tt.prev, err)
+		}
+		next, err := s.Bump(v, "")
+		if err != nil {
+			t.Errorf("%q: Bump(%s): %s", tt.format, tt.prev, err)
+			continue
+		}
+		if got := s.Format(next); got != tt.next {
+			t.Errorf("%q: Bump(%s) at %s = %s, want %s",
+				tt.format, tt.prev, tt.date.Format(time.DateOnly), got, tt.next)
+		}
+	}
+}
+
+func TestCalVerBumpErrors(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", strconv.FormatInt(calVerDate(2026, 10, 18).Unix(), 10))
+	s := &CalVerScheme{}
+	v := semver.MustParse("2026.9.1")
+	for _, part := range []string{"major", "minor", "patch"} {
+		if _, err := s.Bump(v, part); err == nil {
+			t.Errorf("Bump(%s, %q) succeeded, want error", v, part)
+		}
+	}
+	s = &CalVerScheme{Pattern: "YYYY.0M.0D"}
+	if _, err := s.Bump(semver.MustParse("2026.10.18"), ""); err == nil {
+		t.Error("Bump to existing version without MICRO succeeded, want error")
+	}
+	t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+	if _, err := s.Bump(v, ""); err == nil {
+		t.Error("Bump with invalid SOURCE_DATE_EPOCH succeeded, want error")
+	}
+}
+
+func calVerDate(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 12, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,254 @@
+package version
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Config key-value pair.
+type configField struct {
+	key   string
+	value any
+}
+
+// Returns registered type name of source or empty string.
+func sourceTypeName(src Source) string {
+	t := reflect.TypeOf(src)
+	for _, name := range slices.Sorted(maps.Keys(sources)) {
+		if reflect.TypeOf(sources[name]()) == t {
+			return name
+		}
+	}
+	return ""
+}
+
+func vPrefixName(mode VPrefixMode) string {
+	switch mode {
+	case VPrefixTrue:
+		return "true"
+	case VPrefixFalse:
+		return "false"
+	}
+	return "auto"
+}
+
+// Returns source config fields in the same form as they are written in
+// config: Type and VPrefix first, then type-specific options.
+// Zero values are omitted.
+func sourceFields(swm SourceWithMeta) []configField {
+	fields := []configField{
+		{"Type", sourceTypeName(swm.Source)},
+		{"VPrefix", vPrefixName(swm.VPrefix)},
+	}
+	if swm.Disabled {
+		fields = append(fields, configField{"Disabled", true})
+	}
+	if swm.Dialect != "" {
+		fields = append(fields, configField{"Dialect", swm.Dialect})
+	}
+	if swm.Format != "" {
+		fields = append(fields, configField{"Format", swm.Format})
+	}
+	if swm.Policy != "" {
+		fields = append(fields, configField{"Policy", swm.Policy})
+	}
+	if swm.Priority != 0 {
+		fields = append(fields, configField{"Priority", swm.Priority})
+	}
+	v := reflect.ValueOf(swm.Source)
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return fields
+	}
+	for i := range v.NumField() {
+		f := v.Type().Field(i)
+		if !f.IsExported() || v.Field(i).IsZero() {
+			continue
+		}
+		fields = append(fields, configField{f.Name, v.Field(i).Interface()})
+	}
+	return fields
+}
+
+// Returns scheme config in the same form as it is written in config:
+// type name or table with Type and non-zero options.
+func (g *SourceGroup) schemeConfig() any {
+	typ := g.Scheme.Type
+	if typ == "" {
+		typ = "semver"
+	}
+	if g.Scheme.Scheme == nil {
+		return typ
+	}
+	table := map[string]any{typeKey: typ}
+	v := reflect.ValueOf(g.Scheme.Scheme)
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	for _, f := range optionFields(v.Type()) {
+		if field := v.Field(f.index); !field.IsZero() {
+			table[f.name] = field.Interface()
+		}
+	}
+	if len(table) == 1 {
+		return typ
+	}
+	return table
+}
+
+// Config returns resolved config of group (with Extends and Include
+// merged) in the same form as it is written in config files.
+// Unset DefaultVersion, IgnoredFiles, ReadOnlyFiles and Groups are omitted.
+func (g *SourceGroup) Config() map[string]any {
+	m := map[string]any{
+		schemeKey: g.schemeConfig(),
+		strictKey: g.Strict,
+	}
+	if g.DefaultVersion != "" {
+		m[defaultVersionKey] = g.DefaultVersion
+	}
+	if len(g.IgnoredFiles) > 0 {
+		m[ignoredFilesKey] = g.IgnoredFiles
+	}
+	if len(g.ReadOnlyFiles) > 0 {
+		m[readOnlyFilesKey] = g.ReadOnlyFiles
+	}
+	if len(g.Groups) > 0 {
+		m[groupsKey] = g.Groups
+	}
+	srcs := map[string]any{}
+	for name, src := range g.Sources {
+		srcs[name] = SourceConfig(src)
+	}
+	m[sourcesKey] = srcs
+	return m
+}
+
+// SourceConfig returns source options in the same form as they are
+// written in config. Zero values are omitted.
+func SourceConfig(swm SourceWithMeta) map[string]any {
+	m := map[string]any{}
+	for _, field := range sourceFields(swm) {
+		m[field.key] = field.value
+	}
+	return m
+}
+
+// EncodeTOML encodes resolved config of group as TOML.
+func (g *SourceGroup) EncodeTOML() (string, error) {
+	var b strings.Builder
+	m := g.Config()
+	for _, key := range []string{
+		defaultVersionKey, schemeKey, strictKey, ignoredFilesKey, readOnlyFilesKey,
+	} {
+		val, ok := m[key]
+		if _, isTable := val.(map[string]any); !ok || isTable {
+			continue
+		}
+		enc, err := encodeTOMLValue(val)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", key, err)
+		}
+		fmt.Fprintf(&b, "%s = %s\n", key, enc)
+	}
+	if table, ok := m[schemeKey].(map[string]any); ok {
+		fmt.Fprintf(&b, "\n[%s]\n%s = %q\n", schemeKey, typeKey, table[typeKey])
+		delete(table, typeKey)
+		for _, key := range slices.Sorted(maps.Keys(table)) {
+			enc, err := encodeTOMLValue(table[key])
+			if err != nil {
+				return "", fmt.Errorf("%s.%s: %w", schemeKey, key, err)
+			}
+			fmt.Fprintf(&b, "%s = %s\n", key, enc)
+		}
+	}
+	if len(g.Groups) > 0 {
+		fmt.Fprintf(&b, "\n[%s]\n", groupsKey)
+		for _, name := range slices.Sorted(maps.Keys(g.Groups)) {
+			enc, err := encodeTOMLValue(g.Groups[name])
+			if err != nil {
+				return "", fmt.Errorf("%s.%s: %w", groupsKey, name, err)
+			}
+			fmt.Fprintf(&b, "%s = %s\n", name, enc)
+		}
+	}
+	srcs, err := EncodeSourcesTOML(g.Sources, sourcesKey)
+	if err != nil {
+		return "", err
+	}
+	if srcs != "" {
+		b.WriteString("\n" + srcs)
+	}
+	return b.String(), nil
+}
+
+// Encodes sources as TOML tables under table prefix, e.g. "Sources" or
+// "tool.version.Sources".
+func EncodeSourcesTOML(
+	srcs map[Name]SourceWithMeta,
+	table string,
+) (string, error) {
+	var b strings.Builder
+	for i, name := range slices.Sorted(maps.Keys(srcs)) {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "[%s.%s]\n", table, name)
+		for _, field := range sourceFields(srcs[name]) {
+			val, err := encodeTOMLValue(field.value)
+			if err != nil {
+				return "", fmt.Errorf("%s.%s: %w", name, field.key, err)
+			}
+			fmt.Fprintf(&b, "%s = %s\n", field.key, val)
+		}
+	}
+	return b.String(), nil
+}
+
+// Encodes simple values used in source configs.
+func encodeTOMLValue(value any) (string, error) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() { //nolint:exhaustive
+	case reflect.String:
+		return strconv.Quote(v.String()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Slice:
+		items := make([]string, 0, v.Len())
+		for i := range v.Len() {
+			item, err := encodeTOMLValue(v.Index(i).Interface())
+			if err != nil {
+				return "", err
+			}
+			items = append(items, item)
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	case reflect.Map:
+		keys := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		slices.Sort(keys)
+		items := make([]string, 0, len(keys))
+		for _, k := range keys {
+			item, err := encodeTOMLValue(
+				v.MapIndex(reflect.ValueOf(k)).Interface(),
+			)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, strconv.Quote(k)+" = "+item)
+		}
+		return "{ " + strings.Join(items, ", ") + " }", nil
+	}
+	return "", fmt.Errorf("unsupported value type %T", value)
+}
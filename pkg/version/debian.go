@@ -0,0 +1,238 @@
+package version
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/asciimoth/rewrite"
+)
+
+var (
+	debHeaderRegexp = regexp.MustCompile(
+		`^(\S+) \(([^)]+)\) ([^;]+);\s*(.*)$`,
+	)
+	debTrailerRegexp = regexp.MustCompile(`^ -- (.+?)  (.+)$`)
+)
+
+func init() {
+	RegisterSource(
+		"debchangelog",
+		func() Source { return &DebChangelogSource{} },
+	)
+}
+
+// DebChangelogSource reads upstream version of the top debian/changelog
+// entry and on Set prepends a new entry.
+// Empty Package, Maintainer and Distribution are taken from the top entry
+// (Maintainer from DEBFULLNAME and DEBEMAIL env vars first).
+// Empty Date means current time.
+type DebChangelogSource struct {
+	Path         string
+	Package      string
+	Maintainer   string
+	Distribution string
+	Urgency      string
+	Revision     string
+	Message      string
+	Date         string
+}
+
+func (d *DebChangelogSource) IsCanBeLesser() bool {
+	return false
+}
+
+func (d *DebChangelogSource) IsReadOnly() bool {
+	return false
+}
+
+func (d *DebChangelogSource) Files(fs FS) ([]string, error) {
+	return globFiles(fs, d.path())
+}
+
+func (d *DebChangelogSource) Get(fs FS) (*semver.Version, error) {
+	files, err := fs.Glob(d.path())
+	if err != nil {
+		return nil, err
+	}
+	var v *semver.Version
+	for _, file := range files {
+		data, err := rewrite.Read(fs, file)
+		if err != nil {
+			return nil, err
+		}
+		entry, err := parseDebChangelog(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if entry == nil {
+			continue
+		}
+		cv, err := ParseVersion(fs, entry.upstream())
+		if err != nil {
+			return nil, err
+		}
+		if v != nil && !v.Equal(cv) {
+			return nil, errUnsync
+		}
+		v = cv
+	}
+	return v, nil
+}
+
+func (d *DebChangelogSource) Set(v semver.Version, fs FS) error {
+	files, err := fs.Glob(d.path())
+	if err != nil {
+		return err
+	}
+	upstream := strings.Replace(
+		strings.TrimPrefix(FormatVersion(fs, &v), "v"), "-", "~", 1,
+	)
+	changes := false
+	for _, file := range files {
+		data, err := rewrite.Read(fs, file)
+		if err != nil {
+			return err
+		}
+		top, err := parseDebChangelog(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		if top != nil {
+			cv, err := ParseVersion(fs, top.upstream())
+			if err == nil && cv.Equal(&v) {
+				continue
+			}
+		}
+		entry, err := d.entry(top, upstream)
+		if err != nil {
+			return err
+		}
+		err = rewrite.Write(fs, file, append([]byte(entry), data...))
+		if err != nil {
+			return err
+		}
+		changes = true
+	}
+	if changes {
+		return nil
+	}
+	return ErrNoChanges
+}
+
+func (d *DebChangelogSource) path() string {
+	if d.Path == "" {
+		return "debian/changelog"
+	}
+	return d.Path
+}
+
+func (d *DebChangelogSource) entry(top *debEntry, upstream string) (
+	string,
+	error,
+) {
+	if top == nil {
+		top = &debEntry{}
+	}
+	pkg := cmp.Or(d.Package, top.pkg)
+	if pkg == "" {
+		return "", errors.New("debian package name is not configured")
+	}
+	maintainer := d.Maintainer
+	if maintainer == "" && os.Getenv("DEBEMAIL") != "" {
+		maintainer = fmt.Sprintf(
+			"%s <%s>", os.Getenv("DEBFULLNAME"), os.Getenv("DEBEMAIL"),
+		)
+	}
+	maintainer = cmp.Or(maintainer, top.maintainer)
+	if maintainer == "" {
+		return "", errors.New("debian maintainer is not configured")
+	}
+	date, err := changelogDate(d.Date, time.RFC1123Z)
+	if err != nil {
+		return "", err
+	}
+	version := upstream + "-" + cmp.Or(d.Revision, "1")
+	if top.epoch != "" {
+		version = top.epoch + ":" + version
+	}
+	return fmt.Sprintf(
+		"%s (%s) %s; urgency=%s\n\n  * %s\n\n -- %s  %s\n\n",
+		pkg,
+		version,
+		cmp.Or(d.Distribution, top.distribution, "UNRELEASED"),
+		cmp.Or(d.Urgency, "medium"),
+		cmp.Or(d.Message, "New upstream release."),
+		maintainer,
+		date,
+	), nil
+}
+
+// Top entry of debian/changelog.
+type debEntry struct {
+	pkg          string
+	epoch        string
+	version      string // Without epoch
+	distribution string
+	maintainer   string
+}
+
+// Upstream part of version in SemVer-compatible form, e.g.
+// "1:1.2.3~rc1-2" -> "1.2.3-rc1".
+func (e *debEntry) upstream() string {
+	upstream := e.version
+	if i := strings.LastIndex(upstream, "-"); i >= 0 {
+		upstream = upstream[:i]
+	}
+	return strings.Replace(upstream, "~", "-", 1)
+}
+
+// Returns nil if there is no entries.
+func parseDebChangelog(data []byte) (*debEntry, error) {
+	var entry *debEntry
+	for line := range bytes.Lines(data) {
+		str := strings.TrimRight(string(line), "\r\n")
+		if entry == nil {
+			if strings.TrimSpace(str) == "" {
+				continue
+			}
+			m := debHeaderRegexp.FindStringSubmatch(str)
+			if m == nil {
+				return nil, fmt.Errorf("malformed changelog header %q", str)
+			}
+			entry = &debEntry{
+				pkg:          m[1],
+				version:      m[2],
+				distribution: strings.TrimSpace(m[3]),
+			}
+			if epoch, ver, ok := strings.Cut(m[2], ":"); ok {
+				entry.epoch, entry.version = epoch, ver
+			}
+			continue
+		}
+		if m := debTrailerRegexp.FindStringSubmatch(str); m != nil {
+			entry.maintainer = m[1]
+			break
+		}
+	}
+	return entry, nil
+}
+
+// Formats date for changelog entry. Empty date means current time.
+func changelogDate(date, layout string) (string, error) {
+	if date == "" {
+		return time.Now().Format(layout), nil
+	}
+	for _, l := range []string{layout, time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(l, date); err == nil {
+			return t.Format(layout), nil
+		}
+	}
+	return "", fmt.Errorf("cannot parse changelog date %q", date)
+}
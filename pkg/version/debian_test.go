@@ -0,0 +1,80 @@
+package version
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/asciimoth/rewrite"
+)
+
+func TestParseDebChangelog(t *testing.T) {
+	tests := []struct {
+		changelog string
+		entry     *debEntry
+		upstream  string
+	}{
+		{"", nil, ""},
+		{
+			"\nmypkg (1:1.2.3~rc1-2) unstable; urgency=low\n\n  * Fix.\n\n" +
+				" -- Jane Doe <jane@example.com>  Mon, 02 Jan 2006 15:04:05 +0000\n\n" +
+				"mypkg (1.0.0-1) unstable; urgency=low\n",
+			&debEntry{"mypkg", "1", "1.2.3~rc1-2", "unstable", "Jane Doe <jane@example.com>"},
+			"1.2.3-rc1",
+		},
+	}
+	for _, tt := range tests {
+		entry, err := parseDebChangelog([]byte(tt.changelog))
+		if err != nil {
+			t.Errorf("%q: %s", tt.changelog, err)
+			continue
+		}
+		if (entry == nil) != (tt.entry == nil) || entry != nil && *entry != *tt.entry {
+			t.Errorf("%q: entry = %+v, want %+v", tt.changelog, entry, tt.entry)
+			continue
+		}
+		if entry != nil && entry.upstream() != tt.upstream {
+			t.Errorf("%q: upstream = %q, want %q", tt.changelog, entry.upstream(), tt.upstream)
+		}
+	}
+	if _, err := parseDebChangelog([]byte("not a header\n")); err == nil {
+		t.Error("malformed header parsed without error")
+	}
+}
+
+func TestDebChangelogSet(t *testing.T) {
+	t.Setenv("DEBEMAIL", "")
+	top := "mypkg (1:1.2.0-3) unstable; urgency=low\n\n  * Old.\n\n" +
+		" -- Jane Doe <jane@example.com>  Mon, 02 Jan 2006 15:04:05 +0000\n"
+	fs := testFS(t, map[string]string{"debian/changelog": top})
+	src := &DebChangelogSource{Date: "2024-05-01"}
+	v, err := src.Get(fs)
+	if err != nil || v == nil || v.String() != "1.2.0" {
+		t.Fatalf("Get = %v, %v, want 1.2.0", v, err)
+	}
+	if err := src.Set(*v, fs); !errors.Is(err, ErrNoChanges) {
+		t.Errorf("Set of current version: %v, want ErrNoChanges", err)
+	}
+	// Epoch, package, distribution and maintainer are kept,
+	// Debian revision is reset
+	if err := src.Set(*semver.MustParse("1.3.0-rc.1"), fs); err != nil {
+		t.Fatal(err)
+	}
+	data, err := rewrite.Read(fs, "debian/changelog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "mypkg (1:1.3.0~rc.1-1) unstable; urgency=medium\n\n  * New upstream release.\n\n" +
+		" -- Jane Doe <jane@example.com>  Wed, 01 May 2024 00:00:00 +0000\n\n" + top
+	if string(data) != want {
+		t.Errorf("changelog after Set:\n%s\nwant:\n%s", data, want)
+	}
+	if v, err := src.Get(fs); err != nil || v.String() != "1.3.0-rc.1" {
+		t.Errorf("Get after Set = %v, %v, want 1.3.0-rc.1", v, err)
+	}
+	// New changelog requires package and maintainer
+	empty := testFS(t, map[string]string{"debian/changelog": ""})
+	if err := src.Set(*semver.MustParse("1.0.0"), empty); err == nil {
+		t.Error("Set to empty changelog without Package succeeded")
+	}
+}
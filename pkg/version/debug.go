@@ -1,4 +1,4 @@
-package main
+package version
 
 import (
 	"github.com/Masterminds/semver/v3"
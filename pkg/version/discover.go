@@ -0,0 +1,168 @@
+package version
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/pelletier/go-toml"
+)
+
+// Config files in order of preference and subtrees config is kept in.
+// File format is chosen by extension, see configTree.
+var configFiles = []struct {
+	filename string
+	subtree  string
+}{
+	{"version.toml", ""},
+	{".version.toml", ""},
+	{"version.yaml", ""},
+	{"version.yml", ""},
+	{"version.json", ""},
+	{"pyproject.toml", "tool.version"},
+	{"Cargo.toml", "package.metadata.version"},
+	{"Cargo.toml", "workspace.metadata.version"},
+	{"package.json", "version-tool"},
+}
+
+// Parses config file data as JSON or YAML if path has such extension and
+// as TOML otherwise.
+func configTree(data []byte, path string) (*toml.Tree, error) {
+	m := map[string]any{}
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &m)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &m)
+	default:
+		return toml.LoadBytes(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toml.TreeFromMap(m)
+}
+
+// Returns subtree of config file at path holding config or empty string if
+// there is no config in it.
+func configSubtree(tree *toml.Tree, path string) (string, bool) {
+	known := false
+	for _, file := range configFiles {
+		if file.filename != filepath.Base(path) {
+			continue
+		}
+		known = true
+		if file.subtree == "" || tree.Has(file.subtree) {
+			return file.subtree, true
+		}
+	}
+	// Any other file passed explicitly is config as a whole
+	return "", !known
+}
+
+// Returns path of config file in dir or empty string if there is none.
+// Files like pyproject.toml, Cargo.toml and package.json are configs only
+// if they have version table.
+func ConfigInDir(dir string) (string, error) {
+	for _, file := range configFiles {
+		path := filepath.Join(dir, file.filename)
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if file.subtree != "" {
+			tree, err := configTree(data, path)
+			if err != nil || !tree.Has(file.subtree) {
+				continue
+			}
+		}
+		return path, nil
+	}
+	return "", nil
+}
+
+// Looks for config file in dir and its parents up to the repository root
+// (directory containing .git) or the filesystem root.
+// Returns empty string if there is no config.
+func FindConfig(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		path, err := ConfigInDir(dir)
+		if path != "" || err != nil {
+			return path, err
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return "", nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// Returns the nearest of dir and its parents containing .git or empty
+// string if dir is not in repository.
+func FindRepoRoot(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// Loads group from config file at path, which may be outside of fs.
+// Config is taken from [tool.version] table of pyproject.toml files.
+// Overrides are applied on top of config in order.
+func GroupFromConfigFile(
+	fs FS,
+	path string,
+	trace, log, errLog Log,
+	strict bool,
+	overrides ...Override,
+) (*SourceGroup, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := configTree(data, path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	subtree, ok := configSubtree(tree, path)
+	if !ok {
+		return nil, fmt.Errorf("%s has no version config", path)
+	}
+	locate := newConfigLocator(data, path, subtree)
+	gr, err := groupFromTree(
+		tree, path, locate, trace, log, errLog, fs, subtree, overrides,
+	)
+	if err != nil {
+		return nil, wrapConfigError(path, err)
+	}
+	if strict {
+		gr.Strict = strict
+	}
+	return gr, nil
+}
@@ -0,0 +1,33 @@
+package version
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindConfigAndRepoRoot(t *testing.T) {
+	repo := t.TempDir()
+	deep := filepath.Join(repo, "sub", "deep")
+	for _, dir := range []string{filepath.Join(repo, ".git"), deep} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	root, err := FindRepoRoot(deep)
+	if err != nil || root != repo {
+		t.Errorf("FindRepoRoot(%s) = %q, %v, want %q", deep, root, err, repo)
+	}
+	config, err := FindConfig(deep)
+	if err != nil || config != "" {
+		t.Errorf("FindConfig without config = %q, %v", config, err)
+	}
+	want := filepath.Join(repo, "sub", "version.toml")
+	if err := os.WriteFile(want, []byte("Strict = true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	config, err = FindConfig(deep)
+	if err != nil || config != want {
+		t.Errorf("FindConfig = %q, %v, want %q", config, err, want)
+	}
+}
@@ -0,0 +1,32 @@
+// Package version manages project version kept in multiple sources:
+// manifests, lockfiles, git tags and any other files.
+//
+// It is the library behind the `version` CLI:
+//
+//	root, err := os.OpenRoot(".")
+//	if err != nil {
+//		return err
+//	}
+//	logf := func(s string) { fmt.Fprintln(os.Stderr, s) }
+//	group, err := version.GroupFromConfig(
+//		version.FSFromRoot(root), logf, logf, logf, false,
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	current, err := group.Get(nil)
+//	if err != nil {
+//		return err
+//	}
+//	next, err := group.VersionScheme().Bump(current, "minor")
+//	if err != nil {
+//		return err
+//	}
+//	return group.Set(*next, nil)
+//
+// New source types are registered with RegisterSource and may be used in
+// config as `Type`. Sources should parse and format versions with
+// ParseVersion and FormatVersion, so group scheme, source dialect and
+// format are honored, and return ErrNoChanges from Set if file is already
+// up to date.
+package version
@@ -0,0 +1,198 @@
+package version
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/asciimoth/rewrite"
+)
+
+// Config keys handled while merging configs.
+const (
+	extendsKey       = "Extends"
+	includeKey       = "Include"
+	sourcesKey       = "Sources"
+	ignoredFilesKey  = "IgnoredFiles"
+	readOnlyFilesKey = "ReadOnlyFiles"
+)
+
+// Returns directory with config presets,
+// `$XDG_CONFIG_HOME/version/presets` on Linux.
+func presetsDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "version", "presets"), nil
+}
+
+// Resolves config tree Extends and Include references:
+//   - Extends — configs this one is based on, they are merged in order and
+//     then overridden by this one.
+//   - Include — configs merged in order on top of this one.
+//
+// Reference is either a path relative to directory of config containing
+// it, an absolute path or a preset name like "rust" for
+// `<presets dir>/rust.toml`.
+// Sources are merged by name, the later source replaces the earlier one
+// as a whole. IgnoredFiles and ReadOnlyFiles lists are joined. Other keys
+// of the later config override the earlier ones.
+// Every config is validated before merge, problems are reported as
+// ConfigError.
+func resolveConfig(
+	m map[string]any,
+	file string,
+	locate configLocator,
+	fs FS,
+	visited []string,
+) (map[string]any, error) {
+	if err := validateConfig(m, file, locate); err != nil {
+		return nil, err
+	}
+	extends, err := takeRefs(m, extendsKey)
+	if err != nil {
+		return nil, err
+	}
+	includes, err := takeRefs(m, includeKey)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]any{}
+	for _, ref := range extends {
+		base, err := loadConfigRef(ref, file, fs, visited)
+		if err != nil {
+			return nil, err
+		}
+		mergeConfig(result, base)
+	}
+	mergeConfig(result, m)
+	for _, ref := range includes {
+		inc, err := loadConfigRef(ref, file, fs, visited)
+		if err != nil {
+			return nil, err
+		}
+		mergeConfig(result, inc)
+	}
+	return result, nil
+}
+
+// Removes references list under key from config and returns it.
+// Key may hold single string or array of strings.
+func takeRefs(m map[string]any, key string) ([]string, error) {
+	k, ok := findKey(m, key)
+	if !ok {
+		return nil, nil
+	}
+	val := m[k]
+	delete(m, k)
+	switch val := val.(type) {
+	case string:
+		return []string{val}, nil
+	case []any:
+		refs := make([]string, 0, len(val))
+		for _, item := range val {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must contain only strings", key)
+			}
+			refs = append(refs, s)
+		}
+		return refs, nil
+	}
+	return nil, fmt.Errorf("%s must be a string or an array of strings", key)
+}
+
+// Loads and resolves config referenced by Extends or Include of config
+// file. Configs with absolute paths (e.g. presets or --config) are read
+// from OS, others from fs.
+func loadConfigRef(
+	ref, file string,
+	fs FS,
+	visited []string,
+) (map[string]any, error) {
+	var data []byte
+	var err error
+	path := ref
+	switch {
+	case !strings.ContainsAny(ref, `/\`) && filepath.Ext(ref) == "":
+		var dir string
+		dir, err = presetsDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(dir, ref+".toml")
+		data, err = os.ReadFile(path)
+	case filepath.IsAbs(ref):
+		data, err = os.ReadFile(ref)
+	case filepath.IsAbs(file):
+		path = filepath.Join(filepath.Dir(file), ref)
+		data, err = os.ReadFile(path)
+	default:
+		path = filepath.ToSlash(filepath.Join(filepath.Dir(file), ref))
+		data, err = rewrite.Read(fs, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading config %s: %w", ref, err)
+	}
+	if slices.Contains(visited, path) {
+		return nil, fmt.Errorf("config %s extends or includes itself", path)
+	}
+	tree, err := configTree(data, path)
+	if err != nil {
+		return nil, fmt.Errorf("loading config %s: %w", path, err)
+	}
+	locate := newConfigLocator(data, path, "")
+	return resolveConfig(tree.ToMap(), path, locate, fs, append(visited, path))
+}
+
+// Merges over config into base one.
+func mergeConfig(base, over map[string]any) {
+	for key, val := range over {
+		k, ok := findKey(base, key)
+		if !ok {
+			base[key] = val
+			continue
+		}
+		switch {
+		case strings.EqualFold(key, sourcesKey):
+			srcs, ok := base[k].(map[string]any)
+			add, addOk := val.(map[string]any)
+			if !ok || !addOk {
+				break
+			}
+			for name, src := range add {
+				srcs[name] = src
+			}
+			continue
+		case strings.EqualFold(key, ignoredFilesKey),
+			strings.EqualFold(key, readOnlyFilesKey):
+			list, ok := base[k].([]any)
+			add, addOk := val.([]any)
+			if !ok || !addOk {
+				break
+			}
+			for _, item := range add {
+				if !slices.Contains(list, item) {
+					list = append(list, item)
+				}
+			}
+			base[k] = list
+			continue
+		}
+		delete(base, k)
+		base[key] = val
+	}
+}
+
+// Looks for key case-insensitively.
+func findKey(m map[string]any, key string) (string, bool) {
+	for k := range m {
+		if strings.EqualFold(k, key) {
+			return k, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,108 @@
+package version
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Writes files to dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestExtendsRelativeToConfig(t *testing.T) {
+	nop := func(string) {}
+	fs := testFS(t, map[string]string{
+		"version.toml": `Extends = "presets/base.toml"` + "\n" +
+			`IgnoredFiles = ["root"]` + "\n",
+		"presets/base.toml": `Include = ["common.toml"]` + "\n" +
+			`DefaultVersion = "1.0.0"` + "\n" + `IgnoredFiles = ["base"]` + "\n",
+		"presets/common.toml": `DefaultVersion = "2.0.0"` + "\n" +
+			`IgnoredFiles = ["common"]` + "\n",
+		// Must not be picked instead of presets/common.toml
+		"common.toml": `DefaultVersion = "9.9.9"` + "\n",
+	})
+	g, err := GroupFromConfig(fs, nop, nop, nop, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.DefaultVersion != "2.0.0" {
+		t.Errorf("DefaultVersion = %q, want 2.0.0 from presets/common.toml", g.DefaultVersion)
+	}
+	if want := []string{"base", "common", "root"}; !slices.Equal(g.IgnoredFiles, want) {
+		t.Errorf("IgnoredFiles = %q, want %q", g.IgnoredFiles, want)
+	}
+}
+
+// Config given with --config may be outside of project root.
+func TestExtendsOfConfigOutsideRoot(t *testing.T) {
+	nop := func(string) {}
+	fs := testFS(t, map[string]string{
+		"base.toml": `DefaultVersion = "9.9.9"` + "\n",
+	})
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"ci/version.toml": `Extends = ["base.toml", "../shared/extra.toml"]` + "\n",
+		"ci/base.toml":    `DefaultVersion = "1.0.0"` + "\n",
+		"shared/extra.toml": `Include = "more.toml"` + "\n" +
+			`IgnoredFiles = ["extra"]` + "\n",
+		"shared/more.toml": `DefaultVersion = "3.0.0"` + "\n",
+	})
+	g, err := GroupFromConfigFile(fs, filepath.Join(dir, "ci", "version.toml"), nop, nop, nop, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.DefaultVersion != "3.0.0" || !slices.Equal(g.IgnoredFiles, []string{"extra"}) {
+		t.Errorf("DefaultVersion = %q, IgnoredFiles = %q, want 3.0.0 and [extra]",
+			g.DefaultVersion, g.IgnoredFiles)
+	}
+}
+
+func TestExtendsPreset(t *testing.T) {
+	nop := func(string) {}
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	writeFiles(t, filepath.Join(home, "version", "presets"), map[string]string{
+		"rust.toml":   `Include = "extra.toml"` + "\n",
+		"extra.toml":  `DefaultVersion = "4.0.0"` + "\n",
+		"cycle.toml":  `Extends = "cycle2.toml"` + "\n",
+		"cycle2.toml": `Include = ["cycle.toml"]` + "\n",
+	})
+	fs := testFS(t, map[string]string{
+		"version.toml": `Extends = "rust"` + "\n",
+		"cycle.toml":   `Extends = "cycle"` + "\n",
+		"missing.toml": `Include = "nope.toml"` + "\n",
+	})
+	g, err := GroupFromConfig(fs, nop, nop, nop, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.DefaultVersion != "4.0.0" {
+		t.Errorf("DefaultVersion = %q, want 4.0.0 from preset include", g.DefaultVersion)
+	}
+	tests := []struct {
+		ref string
+		err string
+	}{
+		{"cycle", "extends or includes itself"},
+		{"missing.toml", "loading config nope.toml"},
+	}
+	for _, tt := range tests {
+		_, err := loadConfigRef(tt.ref, "version.toml", fs, nil)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("loadConfigRef(%q) error %v, want %q", tt.ref, err, tt.err)
+		}
+	}
+}
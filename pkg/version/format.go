@@ -0,0 +1,312 @@
+package version
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Version parts that can be used in format templates.
+const (
+	partMajor      = "major"
+	partMinor      = "minor"
+	partPatch      = "patch"
+	partPrerelease = "prerelease"
+	partBuild      = "build"
+	partVersion    = "version"
+)
+
+var templatePlaceholder = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// One `part*weight` term of numeric template expression.
+type templateTerm struct {
+	part   string
+	weight uint64
+}
+
+// Template placeholder, either a single part or weighted sum of numeric
+// parts like `major*10000+minor*100+patch`.
+type templateField struct {
+	part  string
+	terms []templateTerm
+}
+
+// versionTemplate is per-source `Format`, e.g. "{major}.{minor}",
+// "{major},{minor},{patch},0" or "{major*10000+minor*100+patch}".
+// The same template is used to parse version back from its text.
+type versionTemplate struct {
+	text   string
+	fields []templateField
+	re     *regexp.Regexp
+}
+
+func parseTemplate(text string) (*versionTemplate, error) {
+	t := &versionTemplate{text: text}
+	var re strings.Builder
+	re.WriteString("^")
+	last := 0
+	for _, m := range templatePlaceholder.FindAllStringSubmatchIndex(text, -1) {
+		re.WriteString(regexp.QuoteMeta(text[last:m[0]]))
+		last = m[1]
+		field, err := parseTemplateField(text[m[2]:m[3]])
+		if err != nil {
+			return nil, fmt.Errorf("invalid Format %q: %w", text, err)
+		}
+		t.fields = append(t.fields, field)
+		switch field.part {
+		case partVersion:
+			re.WriteString(`(v?[0-9A-Za-z.+-]+?)`)
+		case partPrerelease, partBuild:
+			re.WriteString(`([0-9A-Za-z.-]*)`)
+		default:
+			re.WriteString(`(\d+)`)
+		}
+	}
+	re.WriteString(regexp.QuoteMeta(text[last:]) + "$")
+	if len(t.fields) == 0 {
+		return nil, fmt.Errorf("Format %q has no placeholders", text)
+	}
+	var err error
+	t.re, err = regexp.Compile(re.String())
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func parseTemplateField(expr string) (templateField, error) {
+	expr = strings.ReplaceAll(expr, " ", "")
+	switch expr {
+	case partMajor, partMinor, partPatch, partPrerelease, partBuild,
+		partVersion:
+		return templateField{part: expr}, nil
+	}
+	terms := []templateTerm{}
+	for term := range strings.SplitSeq(expr, "+") {
+		part, weight, found := strings.Cut(term, "*")
+		if !found {
+			weight = "1"
+		}
+		if _, err := strconv.ParseUint(part, 10, 64); err == nil {
+			part, weight = weight, part
+		}
+		if !slices.Contains([]string{partMajor, partMinor, partPatch}, part) {
+			return templateField{}, fmt.Errorf("unknown placeholder {%s}", expr)
+		}
+		w, err := strconv.ParseUint(weight, 10, 64)
+		if err != nil || w == 0 {
+			return templateField{}, fmt.Errorf("invalid weight in {%s}", expr)
+		}
+		terms = append(terms, templateTerm{part, w})
+	}
+	// Decoding requires weights from the greatest to the lowest
+	slices.SortFunc(terms, func(a, b templateTerm) int {
+		switch {
+		case a.weight > b.weight:
+			return -1
+		case a.weight < b.weight:
+			return 1
+		}
+		return 0
+	})
+	for i := 1; i < len(terms); i++ {
+		if terms[i].weight == terms[i-1].weight {
+			return templateField{}, fmt.Errorf("ambiguous weights in {%s}", expr)
+		}
+	}
+	return templateField{terms: terms}, nil
+}
+
+// Reports whether weighted sum of parts can be decoded back to them.
+func (f templateField) check(values map[string]uint64, sum uint64) error {
+	for j := 1; j < len(f.terms); j++ {
+		term := f.terms[j]
+		// part*weight must be less than weight of previous term
+		limit := (f.terms[j-1].weight + term.weight - 1) / term.weight
+		if values[term.part] >= limit {
+			return fmt.Errorf(
+				"%s %d must be less than %d", term.part, values[term.part], limit,
+			)
+		}
+	}
+	for _, term := range f.terms {
+		if sum/term.weight != values[term.part] {
+			return errors.New("parts overlap in weighted sum")
+		}
+		sum %= term.weight
+	}
+	return nil
+}
+
+// Reports which of version parts are present in template: numeric ones
+// and prerelease.
+func (t *versionTemplate) parts() []string {
+	parts := []string{}
+	for _, f := range t.fields {
+		switch f.part {
+		case partVersion:
+			return fullParts()
+		case partMajor, partMinor, partPatch, partPrerelease:
+			parts = appendUnique(parts, f.part)
+		}
+		for _, term := range f.terms {
+			parts = appendUnique(parts, term.part)
+		}
+	}
+	return parts
+}
+
+// Returns parts of version kept by source without Format.
+func fullParts() []string {
+	return []string{partMajor, partMinor, partPatch, partPrerelease}
+}
+
+// Reports whether parts describe whole version, i.e. all numeric parts and
+// prerelease.
+func isFullVersion(parts []string) bool {
+	for _, part := range fullParts() {
+		if !slices.Contains(parts, part) {
+			return false
+		}
+	}
+	return true
+}
+
+// Renders version, fails if weighted sum of parts can't be decoded back,
+// e.g. patch 100 in {major*10000+minor*100+patch}.
+func (t *versionTemplate) render(
+	v *semver.Version,
+	base Scheme,
+) (string, error) {
+	values := map[string]uint64{
+		partMajor: v.Major(),
+		partMinor: v.Minor(),
+		partPatch: v.Patch(),
+	}
+	var err error
+	i := 0
+	text := templatePlaceholder.ReplaceAllStringFunc(t.text, func(string) string {
+		field := t.fields[i]
+		i++
+		switch field.part {
+		case partVersion:
+			return base.Format(v)
+		case partPrerelease:
+			return v.Prerelease()
+		case partBuild:
+			return v.Metadata()
+		case "":
+		default:
+			return strconv.FormatUint(values[field.part], 10)
+		}
+		var sum uint64
+		for _, term := range field.terms {
+			sum += values[term.part] * term.weight
+		}
+		if e := field.check(values, sum); e != nil && err == nil {
+			err = fmt.Errorf("%s: %w for Format %q", verToString(v), e, t.text)
+		}
+		return strconv.FormatUint(sum, 10)
+	})
+	if err != nil {
+		return "", err
+	}
+	return text, nil
+}
+
+func (t *versionTemplate) parse(
+	s string,
+	base Scheme,
+) (*semver.Version, error) {
+	m := t.re.FindStringSubmatch(s)
+	if m == nil {
+		return nil, fmt.Errorf("%q does not match Format %q", s, t.text)
+	}
+	values := map[string]uint64{}
+	var pre, build string
+	for i, field := range t.fields {
+		val := m[i+1]
+		switch field.part {
+		case partVersion:
+			return base.Parse(val)
+		case partPrerelease:
+			pre = val
+			continue
+		case partBuild:
+			build = val
+			continue
+		}
+		n, err := strconv.ParseUint(val, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if field.part != "" {
+			values[field.part] = n
+			continue
+		}
+		for j, term := range field.terms {
+			values[term.part] = n / term.weight
+			n %= term.weight
+			if j == len(field.terms)-1 && n != 0 {
+				return nil, fmt.Errorf("%q does not match Format %q", s, t.text)
+			}
+		}
+	}
+	text := fmt.Sprintf(
+		"%d.%d.%d", values[partMajor], values[partMinor], values[partPatch],
+	)
+	if pre != "" {
+		text += "-" + pre
+	}
+	if build != "" {
+		text += "+" + build
+	}
+	return semver.NewVersion(text)
+}
+
+// templateScheme writes and reads versions of source with `Format`.
+type templateScheme struct {
+	Scheme
+	template *versionTemplate
+}
+
+func (s *templateScheme) Parse(str string) (*semver.Version, error) {
+	return s.template.parse(str, s.Scheme)
+}
+
+// Formats version, versions not fitting template are rejected by
+// SourceGroup before they are written.
+func (s *templateScheme) Format(v *semver.Version) string {
+	text, _ := s.template.render(v, s.Scheme)
+	return text
+}
+
+// Reports whether versions are equal in parts present in both of them.
+// Prerelease is compared only for full versions, others can't keep it.
+func partiallyEqual(a, b *semver.Version, parts []string) bool {
+	if isFullVersion(parts) {
+		return a.Equal(b)
+	}
+	for _, part := range parts {
+		switch part {
+		case partMajor:
+			if a.Major() != b.Major() {
+				return false
+			}
+		case partMinor:
+			if a.Minor() != b.Minor() {
+				return false
+			}
+		case partPatch:
+			if a.Patch() != b.Patch() {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,203 @@
+package version
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+func TestTemplateRoundTrip(t *testing.T) {
+	tests := []struct {
+		format  string
+		version string
+		text    string
+	}{
+		{"{major}.{minor}", "1.2.0", "1.2"},
+		{"{major},{minor},{patch},0", "1.2.3", "1,2,3,0"},
+		{"{major*10000+minor*100+patch}", "1.2.3", "10203"},
+		{"{major*10000 + minor*100 + patch}", "10.0.99", "100099"},
+		{"v{version}", "1.2.3-rc.1", "v1.2.3-rc.1"},
+		{"{major}.{minor}.{patch}-{prerelease}", "1.2.3-rc.1", "1.2.3-rc.1"},
+		{"{major}.{minor}.{patch}+{build}", "1.2.3+abc", "1.2.3+abc"},
+	}
+	for _, tt := range tests {
+		tmpl, err := parseTemplate(tt.format)
+		if err != nil {
+			t.Fatalf("parseTemplate(%q): %s", tt.format, err)
+		}
+		v := semver.MustParse(tt.version)
+		if got, err := tmpl.render(v, &SemVerScheme{}); err != nil || got != tt.text {
+			t.Errorf("%q: render(%s) = %q, %v, want %q", tt.format, v, got, err, tt.text)
+		}
+		parsed, err := tmpl.parse(tt.text, &SemVerScheme{})
+		if err != nil {
+			t.Errorf("%q: parse(%q): %s", tt.format, tt.text, err)
+			continue
+		}
+		if !parsed.Equal(v) || parsed.Metadata() != v.Metadata() {
+			t.Errorf("%q: parse(%q) = %s, want %s", tt.format, tt.text, parsed, v)
+		}
+	}
+}
+
+// Parts not fitting weighted sum can't be rendered, otherwise they are
+// read back as other version, e.g. 1.2.100 as 1.3.0.
+func TestTemplateOverflow(t *testing.T) {
+	tests := []struct {
+		format  string
+		version string
+		err     string
+	}{
+		{"{major*10000+minor*100+patch}", "1.2.99", ""},
+		{"{major*10000+minor*100+patch}", "1.99.0", ""},
+		{"{major*10000+minor*100+patch}", "100.0.0", ""},
+		{"{major*10000+minor*100+patch}", "1.2.100", "patch 100 must be less than 100"},
+		{"{major*10000+minor*100+patch}", "1.100.0", "minor 100 must be less than 100"},
+		{"{major*100+minor*7+patch}", "1.14.1", ""},
+		{"{major*100+minor*7+patch}", "1.14.2", "parts overlap in weighted sum"},
+		{"{major*100+minor*7+patch}", "1.2.7", "patch 7 must be less than 7"},
+		{"{major*100+minor*7+patch}", "1.15.0", "minor 15 must be less than 15"},
+		{"{major}.{minor*100+patch}", "1.2.100", "patch 100 must be less than 100"},
+	}
+	for _, tt := range tests {
+		tmpl, err := parseTemplate(tt.format)
+		if err != nil {
+			t.Fatalf("parseTemplate(%q): %s", tt.format, err)
+		}
+		v := semver.MustParse(tt.version)
+		text, err := tmpl.render(v, &SemVerScheme{})
+		if tt.err != "" {
+			want := tt.version + ": " + tt.err + " for Format \"" + tt.format + "\""
+			if err == nil || err.Error() != want {
+				t.Errorf("%q: render(%s) = %q, %v, want error %q",
+					tt.format, v, text, err, want)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: render(%s): %s", tt.format, v, err)
+			continue
+		}
+		parsed, err := tmpl.parse(text, &SemVerScheme{})
+		if err != nil || !parsed.Equal(v) {
+			t.Errorf("%q: %s is read back as %v, %v", tt.format, v, parsed, err)
+		}
+	}
+}
+
+// Version not fitting Format of source is not written to it.
+func TestSetRejectsOverflow(t *testing.T) {
+	src := &memSource{version: semver.MustParse("1.2.99")}
+	nop := func(string) {}
+	g, err := NewGroupSource("", map[Name]SourceWithMeta{
+		"Code": {Format: "{major*10000+minor*100+patch}", Source: src},
+	}, false, nop, nop, nop, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Set(*semver.MustParse("1.2.100"), nil); err == nil {
+		t.Error("Set of version not fitting Format succeeded")
+	}
+	if src.written {
+		t.Errorf("version %s not fitting Format was written", src.version)
+	}
+	if err := g.Set(*semver.MustParse("1.3.0"), nil); err != nil || !src.written {
+		t.Errorf("Set of version fitting Format: %v", err)
+	}
+}
+
+func TestTemplateErrors(t *testing.T) {
+	for _, format := range []string{
+		"1.2.3",
+		"{major}.{micro}",
+		"{major*100+minor*100}",
+		"{major*0}",
+	} {
+		if _, err := parseTemplate(format); err == nil {
+			t.Errorf("parseTemplate(%q) succeeded, want error", format)
+		}
+	}
+	tmpl, err := parseTemplate("{major*100+minor}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tmpl.parse("1.2", &SemVerScheme{}); err == nil {
+		t.Error("parse of text not matching Format succeeded")
+	}
+}
+
+func TestTemplateParts(t *testing.T) {
+	tests := []struct {
+		format string
+		parts  []string
+		full   bool
+	}{
+		{"{major}.{minor}", []string{partMajor, partMinor}, false},
+		{"{major}.{minor}.{patch}", []string{partMajor, partMinor, partPatch}, false},
+		{"{major*10000+minor*100+patch}", []string{partMajor, partMinor, partPatch}, false},
+		{"{major}.{minor}.{patch}-{prerelease}", fullParts(), true},
+		{"v{version}", fullParts(), true},
+	}
+	for _, tt := range tests {
+		tmpl, err := parseTemplate(tt.format)
+		if err != nil {
+			t.Fatalf("parseTemplate(%q): %s", tt.format, err)
+		}
+		parts := tmpl.parts()
+		if !slices.Equal(parts, tt.parts) {
+			t.Errorf("%q: parts() = %v, want %v", tt.format, parts, tt.parts)
+		}
+		if isFullVersion(parts) != tt.full {
+			t.Errorf("%q: isFullVersion = %t, want %t", tt.format, !tt.full, tt.full)
+		}
+	}
+}
+
+func TestPartiallyEqual(t *testing.T) {
+	numeric := []string{partMajor, partMinor, partPatch}
+	tests := []struct {
+		a, b  string
+		parts []string
+		want  bool
+	}{
+		{"1.2.3", "1.2.3", fullParts(), true},
+		{"1.3.0-rc.1", "1.3.0", fullParts(), false},
+		// Template without prerelease can't keep it
+		{"1.3.0-rc.1", "1.3.0", numeric, true},
+		{"1.3.1", "1.3.0", numeric, false},
+		{"1.3.7", "1.3.0", []string{partMajor, partMinor}, true},
+		{"1.4.0", "1.3.0", []string{partMajor, partMinor}, false},
+	}
+	for _, tt := range tests {
+		got := partiallyEqual(semver.MustParse(tt.a), semver.MustParse(tt.b), tt.parts)
+		if got != tt.want {
+			t.Errorf("partiallyEqual(%s, %s, %v) = %t, want %t",
+				tt.a, tt.b, tt.parts, got, tt.want)
+		}
+	}
+}
+
+// Source with Format lacking prerelease must not be authoritative over
+// one keeping whole version, otherwise prerelease is lost.
+func TestAuthoritativeSkipsPartialFormat(t *testing.T) {
+	g := &SourceGroup{}
+	reports := []Report{
+		{
+			Name:    "Pkg",
+			Version: semver.MustParse("1.3.0"),
+			Meta: SourceWithMeta{
+				Format: "{major}.{minor}.{patch}",
+				Source: &TOMLSource{},
+			},
+		},
+		{
+			Name:    "Js",
+			Version: semver.MustParse("1.3.0-rc.1"),
+			Meta:    SourceWithMeta{Source: &JSONSource{}},
+		},
+	}
+	if got := g.authoritative(reports); got == nil || got.Name != "Js" {
+		t.Errorf("authoritative() = %v, want Js", got)
+	}
+}
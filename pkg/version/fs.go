@@ -1,4 +1,4 @@
-package main
+package version
 
 import (
 	"io/fs"
@@ -88,20 +88,24 @@ func (f *filteredFS) Glob(pattern string) ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
-	result := make([]string, len(m))
+	result := make([]string, 0, len(m))
 	for _, m := range m {
-		skip := false
-		for _, p := range f.patterns {
-			t, err := path.Match(p, m)
-			if err != nil || t {
-				skip = true
-				continue
-			}
-		}
-		if skip {
+		if matchesAny(f.patterns, m) {
 			continue
 		}
 		result = append(result, m)
 	}
 	return result, nil
 }
+
+// Reports whether name matches any of patterns, invalid ones match
+// everything.
+func matchesAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		t, err := path.Match(p, name)
+		if err != nil || t {
+			return true
+		}
+	}
+	return false
+}
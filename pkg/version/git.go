@@ -1,10 +1,11 @@
-package main
+package version
 
 import (
 	"bufio"
 	"bytes"
 	"os/exec"
 	"regexp"
+	"strings"
 
 	"github.com/Masterminds/semver/v3"
 )
@@ -18,7 +19,7 @@ func init() {
 	RegisterDefaultSource("Git", SourceWithMeta{
 		VPrefix: VPrefixAuto,
 		Source:  &GitSource{},
-	})
+	}, ".git")
 }
 
 type GitSource struct {
@@ -35,7 +36,7 @@ func (g *GitSource) IsReadOnly() bool {
 	return g.ReadOnly
 }
 
-func (g *GitSource) Get(_ FS) (*semver.Version, error) {
+func (g *GitSource) Get(fs FS) (*semver.Version, error) {
 	cmd, err := constructCmd([]string{"git", "tag"}, g.CD, g.Env)
 	if err != nil {
 		return nil, err
@@ -44,13 +45,18 @@ func (g *GitSource) Get(_ FS) (*semver.Version, error) {
 	if err != nil {
 		return nil, err
 	}
+	scheme := schemeOf(fs)
 	tags, err := parseSemverTagsFromReader(bytes.NewReader(out))
 	if err != nil {
 		return nil, err
 	}
+	if _, ok := scheme.(*SemVerScheme); !ok {
+		// Tags of other schemes are filtered by their Parse
+		tags = strings.Fields(string(out))
+	}
 	var maxTag *semver.Version
 	for _, tag := range tags {
-		t, err := semver.NewVersion(tag)
+		t, err := scheme.Parse(tag)
 		if err != nil {
 			continue
 		}
@@ -62,11 +68,11 @@ func (g *GitSource) Get(_ FS) (*semver.Version, error) {
 	return maxTag, nil
 }
 
-func (g *GitSource) Set(v semver.Version, _ FS) error {
+func (g *GitSource) Set(v semver.Version, fs FS) error {
 	if g.ReadOnly {
 		return nil
 	}
-	str := verToString(&v)
+	str := FormatVersion(fs, &v)
 	cmd := exec.Command("git", "tag", "-f", str) //nolint:gosec,noctx
 	_, err := cmd.Output()
 	return err
@@ -0,0 +1,698 @@
+package version
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/asciimoth/rewrite"
+	"github.com/pelletier/go-toml"
+)
+
+var errSubtreeNotFound = errors.New("subtree not found")
+
+type Log = func(string)
+
+type VPrefixMode = int
+
+const (
+	VPrefixAuto VPrefixMode = iota
+	VPrefixTrue
+	VPrefixFalse
+)
+
+func NewVPrefixMode(name string) VPrefixMode {
+	if strings.EqualFold(name, "True") {
+		return VPrefixTrue
+	}
+	if strings.EqualFold(name, "False") {
+		return VPrefixFalse
+	}
+	return VPrefixAuto
+}
+
+type SourceWithMeta struct {
+	VPrefix  VPrefixMode
+	Disabled bool
+	// Version dialect used by source, e.g. "pep440"
+	Dialect string
+	// Template of version text in source, e.g. "{major}.{minor}"
+	Format string
+	// Rule for comparing with other sources, e.g. "lag" or ">= Git"
+	Policy string
+	// Sources with higher priority are more authoritative
+	Priority int
+	Source   Source
+}
+
+func (swm *SourceWithMeta) UnmarshalTOML(data any) error {
+	m, ok := data.(map[string]any)
+	if !ok {
+		return fmt.Errorf(
+			"expected table for source, got %T",
+			data,
+		) //nolint:err113
+	}
+
+	// find type key case-insensitively
+	var typ string
+	for k, v := range m {
+		if strings.EqualFold(k, "Type") {
+			if s, ok := v.(string); ok {
+				typ = s
+			} else {
+				return fmt.Errorf("type field is not a string (got %T)", v) //nolint:err113
+			}
+			break
+		}
+	}
+	if typ == "" {
+		return errors.New("missing type field for source") //nolint:err113
+	}
+
+	// extract VPrefix if present (case-insensitive key match)
+	for k, v := range m {
+		if strings.EqualFold(k, "VPrefix") {
+			switch val := v.(type) {
+			case string:
+				swm.VPrefix = NewVPrefixMode(val)
+			case bool:
+				if val {
+					swm.VPrefix = VPrefixTrue
+				} else {
+					swm.VPrefix = VPrefixFalse
+				}
+			case int64:
+				swm.VPrefix = VPrefixMode(val)
+			case int:
+				swm.VPrefix = val
+			case float64:
+				swm.VPrefix = int(val)
+			}
+		} else if strings.EqualFold(k, "Disabled") {
+			if strings.EqualFold(fmt.Sprint(v), "true") {
+				swm.Disabled = true
+			}
+		} else if strings.EqualFold(k, "Dialect") {
+			dialect := strings.ToLower(fmt.Sprint(v))
+			if _, ok := dialects[dialect]; !ok {
+				return fmt.Errorf("unknown version dialect %s", dialect)
+			}
+			swm.Dialect = dialect
+		} else if strings.EqualFold(k, "Format") {
+			format, ok := v.(string)
+			if !ok {
+				return fmt.Errorf("format field is not a string (got %T)", v) //nolint:err113
+			}
+			if _, err := parseTemplate(format); err != nil {
+				return err
+			}
+			swm.Format = format
+		} else if strings.EqualFold(k, "Policy") {
+			p, err := parsePolicy(fmt.Sprint(v))
+			if err != nil {
+				return err
+			}
+			swm.Policy = p.String()
+		} else if strings.EqualFold(k, "Priority") {
+			switch val := v.(type) {
+			case int64:
+				swm.Priority = int(val)
+			case uint64:
+				swm.Priority = int(val) //nolint:gosec
+			case int:
+				swm.Priority = val
+			case float64:
+				swm.Priority = int(val)
+			default:
+				return fmt.Errorf("priority field is not a number (got %T)", v) //nolint:err113
+			}
+		}
+	}
+
+	constructor := sources[typ]
+	if constructor == nil {
+		return fmt.Errorf(
+			"there is no registered constructor for source type %s",
+			typ,
+		)
+	}
+	instance := constructor()
+
+	// Marshal the parsed map back to TOML bytes, then unmarshal into the concrete instance.
+	// This keeps decoding behavior consistent with the toml tags / rules.
+	b, err := toml.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if err := toml.Unmarshal(b, instance); err != nil {
+		return err
+	}
+
+	swm.Source = instance
+	return nil
+}
+
+type Name = string
+
+type Report struct {
+	Version *semver.Version
+	Meta    SourceWithMeta
+	Name    Name
+}
+
+type SourceGroup struct {
+	DefaultVersion  string
+	Scheme          SchemeConfig
+	Sources         map[Name]SourceWithMeta
+	Strict          bool
+	Trace, Log, Err Log
+	getFS           FS
+	setFS           FS
+	IgnoredFiles    []string
+	ReadOnlyFiles   []string
+	// Named lists of sources, e.g. for selecting them with `--group`
+	Groups map[string][]Name
+}
+
+func NewGroupSource(
+	defaultVersion string,
+	sources map[Name]SourceWithMeta,
+	strict bool,
+	trace, log, elog Log,
+	fs FS,
+	ignoredFiles, roFiles []string,
+) (*SourceGroup, error) {
+	ifs := &filteredFS{fs, ignoredFiles}
+	rofs := &filteredFS{ifs, roFiles}
+	gs := &SourceGroup{
+		defaultVersion,
+		SchemeConfig{},
+		sources,
+		strict,
+		trace, log, elog,
+		ifs, rofs,
+		ignoredFiles, roFiles,
+		nil,
+	}
+	err := gs.verify()
+	if err != nil {
+		return nil, err
+	}
+	return gs, nil
+}
+
+// GroupFromConfig loads group from the first config file found in fs root
+// or from detected default sources if there is none.
+// Overrides are applied on top of config in order.
+func GroupFromConfig(
+	fs FS,
+	trace, log, errLog Log,
+	strict bool,
+	overrides ...Override,
+) (*SourceGroup, error) {
+	for _, file := range configFiles {
+		bytes, err := rewrite.Read(fs, file.filename)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		tree, err := configTree(bytes, file.filename)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.filename, err)
+		}
+		locate := newConfigLocator(bytes, file.filename, file.subtree)
+		gr, err := groupFromTree(
+			tree, file.filename, locate, trace, log, errLog, fs, file.subtree,
+			overrides,
+		)
+		if errors.Is(err, errSubtreeNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, wrapConfigError(file.filename, err)
+		}
+		if strict {
+			gr.Strict = strict
+		}
+		return gr, nil
+	}
+	srcs := DetectDefaultSources(fs)
+	if len(overrides) == 0 {
+		return NewGroupSource(
+			"",
+			srcs,
+			strict,
+			func(_ string) {},
+			log,
+			errLog,
+			fs,
+			[]string{},
+			[]string{},
+		)
+	}
+	// Default sources are overridden in config form
+	config := map[string]any{}
+	for name, src := range srcs {
+		config[name] = SourceConfig(src)
+	}
+	tree, err := toml.TreeFromMap(map[string]any{sourcesKey: config})
+	if err != nil {
+		return nil, err
+	}
+	gr, err := groupFromMap(
+		tree.ToMap(), "", noLocator, trace, log, errLog, fs, overrides,
+	)
+	if err != nil {
+		return nil, err
+	}
+	gr.Trace = func(_ string) {}
+	if strict {
+		gr.Strict = strict
+	}
+	return gr, nil
+}
+
+func GroupFromToml(
+	data []byte,
+	trace, log, errLog Log,
+	fs FS,
+	subtree string,
+) (*SourceGroup, error) {
+	tree, err := toml.LoadBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	locate := newConfigLocator(data, "", subtree)
+	return groupFromTree(tree, "", locate, trace, log, errLog, fs, subtree, nil)
+}
+
+// Loads group from config tree of file (used in error messages only).
+func groupFromTree(
+	tree *toml.Tree,
+	file string,
+	locate configLocator,
+	trace, log, errLog Log,
+	fs FS,
+	subtree string,
+	overrides []Override,
+) (*SourceGroup, error) {
+	stree := tree.Get(subtree)
+	if stree == nil {
+		return nil, errSubtreeNotFound
+	}
+	if sub, ok := stree.(*toml.Tree); ok {
+		tree = sub
+	}
+	m, err := resolveConfig(tree.ToMap(), file, locate, fs, nil)
+	if err != nil {
+		return nil, err
+	}
+	return groupFromMap(m, file, locate, trace, log, errLog, fs, overrides)
+}
+
+// Loads group from resolved and validated config map.
+func groupFromMap(
+	m map[string]any,
+	file string,
+	locate configLocator,
+	trace, log, errLog Log,
+	fs FS,
+	overrides []Override,
+) (*SourceGroup, error) {
+	if len(overrides) > 0 {
+		if err := applyOverrides(m, overrides, trace); err != nil {
+			return nil, err
+		}
+		// Overridden values are not in config file
+		if err := validateConfig(m, "", noLocator); err != nil {
+			return nil, err
+		}
+	}
+	tree, err := toml.TreeFromMap(m)
+	if err != nil {
+		return nil, err
+	}
+	var gs SourceGroup
+	if err := tree.Unmarshal(&gs); err != nil {
+		return nil, err
+	}
+	if err := checkDefaultVersion(&gs, m, file, locate); err != nil {
+		return nil, err
+	}
+
+	ifs := &filteredFS{fs, gs.IgnoredFiles}
+	rofs := &filteredFS{ifs, gs.ReadOnlyFiles}
+	gs.Trace = trace
+	gs.Log = log
+	gs.Err = errLog
+	gs.getFS = ifs
+	gs.setFS = rofs
+	err = gs.verify()
+	if err != nil {
+		return nil, err
+	}
+	return &gs, nil
+}
+
+// Returns versioning scheme of group, SemVer by default.
+func (g *SourceGroup) VersionScheme() Scheme { //nolint:ireturn
+	if g.Scheme.Scheme == nil {
+		return &SemVerScheme{}
+	}
+	return g.Scheme.Scheme
+}
+
+// Formats version according to group scheme.
+func (g *SourceGroup) Format(v *semver.Version) string {
+	return g.VersionScheme().Format(v)
+}
+
+// Formats version with template like "{major}.{minor}" (see
+// SourceWithMeta.Format), {version} is formatted with group scheme.
+func (g *SourceGroup) FormatTemplate(
+	v *semver.Version,
+	template string,
+) (string, error) {
+	t, err := parseTemplate(template)
+	if err != nil {
+		return "", err
+	}
+	return t.render(v, g.VersionScheme())
+}
+
+// Returns default version parsed with group scheme.
+func (g *SourceGroup) ParseDefaultVersion() (*semver.Version, error) {
+	if g.DefaultVersion == "" {
+		return semver.NewVersion("0.1.0")
+	}
+	return g.VersionScheme().Parse(g.DefaultVersion)
+}
+
+// Returns project fs with IgnoredFiles filtered out.
+func (g *SourceGroup) FS() FS { //nolint:ireturn
+	return g.getFS
+}
+
+// Reports whether writing file is blocked by ReadOnlyFiles.
+func (g *SourceGroup) IsReadOnlyFile(name string) bool {
+	return matchesAny(g.ReadOnlyFiles, name)
+}
+
+// Returns fs passed to source.
+func (g *SourceGroup) SourceFS(fs FS, src SourceWithMeta) FS { //nolint:ireturn
+	scheme := g.VersionScheme()
+	if wrap, ok := dialects[src.Dialect]; ok {
+		scheme = wrap(scheme)
+	}
+	if t, err := parseTemplate(src.Format); src.Format != "" && err == nil {
+		scheme = &templateScheme{scheme, t}
+	}
+	return &schemeFS{fs, scheme}
+}
+
+// Returns version parts kept by source.
+func (swm SourceWithMeta) parts() []string {
+	if t, err := parseTemplate(swm.Format); swm.Format != "" && err == nil {
+		return t.parts()
+	}
+	return fullParts()
+}
+
+func (g *SourceGroup) Filter(names []Name) map[Name]SourceWithMeta {
+	srcs := make(map[Name]SourceWithMeta)
+	for name, src := range g.Sources {
+		if !slices.Contains(names, "none") && slices.Contains(names, name) {
+			srcs[name] = src
+		}
+	}
+	return srcs
+}
+
+func (g *SourceGroup) Fetch( //nolint:nonamedreturns
+	names []Name,
+) (reports []Report, vp bool, err error) {
+	vp = false
+	reports = []Report{}
+	sources := g.Sources
+	if len(names) > 0 {
+		sources = g.Filter(names)
+	}
+	for name, src := range sources {
+		if src.Disabled {
+			g.Trace(fmt.Sprintf("  %s skipped as disabled", name))
+			continue
+		}
+		v, e := src.Source.Get(g.SourceFS(g.getFS, src))
+		if e != nil {
+			g.Err(fmt.Sprintf("  %s failed with: %s", name, e))
+			if err == nil {
+				err = e
+			}
+			continue
+		}
+		vp = vp || HasVPrefix(v)
+		// For some reasons sometimes semver.NewVersion rurns nil for
+		// both version and error
+		reports = append(reports, Report{v, src, name})
+	}
+	if err != nil {
+		return
+	}
+	// Sorting reporst for better log messages later
+	slices.SortFunc(reports, func(a, b Report) int {
+		if b.Version == nil {
+			if a.Version == nil {
+				return 0
+			}
+			return -1
+		}
+		if a.Version == nil {
+			return 1
+		}
+		return b.Version.Compare(a.Version)
+	})
+	return
+}
+
+func (g *SourceGroup) GetMax(names []Name, versions []semver.Version) (
+	*semver.Version,
+	error,
+) {
+	var err error
+	var version *semver.Version
+	reports := []Report{}
+	if len(names) > 0 {
+		reports, _, err = g.Fetch(names)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range versions {
+		if version == nil {
+			version = &v
+			continue
+		}
+		if v.GreaterThan(version) {
+			version = &v
+		}
+	}
+	for _, r := range reports {
+		if version == nil {
+			version = r.Version
+		}
+		if r.Version != nil && r.Version.GreaterThan(version) {
+			version = r.Version
+		}
+	}
+	if version == nil {
+		return g.ParseDefaultVersion()
+	}
+	return version, nil
+}
+
+// Returns only first error.
+// If any of sources reports version with leading v, result have leading v too.
+func (g *SourceGroup) Get(names []Name) (version *semver.Version, err error) {
+	g.Log("fetching versions from sources...")
+	// Collecting reports from all Sources
+	reports, vp, err := g.Fetch(names)
+	if err != nil {
+		return
+	}
+	// Verifying that reported versions are matching source policies
+	ref := g.authoritative(reports)
+	if ref != nil {
+		version = ref.Version
+	}
+	for _, r := range reports {
+		if r.Version == nil {
+			g.Trace(fmt.Sprintf("  %s reports no version", r.Name))
+			continue
+		}
+		if r.Name == ref.Name {
+			g.Log(fmt.Sprintf("  %s reports version: %s", r.Name, g.Format(r.Version)))
+			continue
+		}
+		p := g.policy(r.Meta)
+		e := p.check(r.Version, version, r.Meta.parts(), reports, g.Format)
+		if e != nil {
+			g.Err(fmt.Sprintf(
+				"  %s report version %s violating %q policy: %s",
+				r.Name, g.Format(r.Version), p, e,
+			))
+			if err == nil {
+				err = errors.New("  sources reporting different versions")
+			}
+			continue
+		}
+		if partiallyEqual(version, r.Version, r.Meta.parts()) {
+			g.Log(fmt.Sprintf("  %s report version: %s", r.Name, g.Format(r.Version)))
+			continue
+		}
+		g.Log(fmt.Sprintf(
+			"  %s report version: %s (allowed by %q policy)",
+			r.Name, g.Format(r.Version), p,
+		))
+	}
+	if vp && version != nil {
+		version = AddVPrefix(version)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if version == nil {
+		g.Log("  no version found in project, using default one")
+		return g.ParseDefaultVersion()
+	}
+	return version, nil
+}
+
+// Returns policy of source.
+// Sources without one may lag if they can be lesser and group is not strict.
+func (g *SourceGroup) policy(src SourceWithMeta) policy {
+	if p, err := parsePolicy(src.Policy); src.Policy != "" && err == nil {
+		return p
+	}
+	if !g.Strict && src.Source.IsCanBeLesser() {
+		return policy{kind: policyLag}
+	}
+	return policy{kind: policyEqual}
+}
+
+// Returns report of the most authoritative source: one with the highest
+// priority, then one keeping whole version with "equal" policy, then
+// one keeping whole version, then the greatest version.
+func (g *SourceGroup) authoritative(reports []Report) *Report {
+	rank := func(r Report) []int {
+		tier := 0
+		if isFullVersion(r.Meta.parts()) {
+			tier = 1
+			if g.policy(r.Meta).kind == policyEqual {
+				tier = 2
+			}
+		}
+		return []int{r.Meta.Priority, tier}
+	}
+	var best *Report
+	for i, r := range reports {
+		if r.Version == nil {
+			continue
+		}
+		if best == nil || slices.Compare(rank(r), rank(*best)) > 0 {
+			best = &reports[i]
+		}
+	}
+	return best
+}
+
+// Return only first error.
+func (g *SourceGroup) Set(v semver.Version, names []Name) (err error) {
+	g.Log("writing versions to sources...")
+	sources := g.Sources
+	if len(names) > 0 {
+		sources = g.Filter(names)
+	}
+	for name, src := range sources {
+		if src.Disabled {
+			g.Trace(fmt.Sprintf("  %s skipped as disabled", name))
+			continue
+		}
+		if src.Source.IsReadOnly() {
+			g.Trace(fmt.Sprintf("  %s skipped as readonly", name))
+			continue
+		}
+		e := g.setSource(src, v)
+		if errors.Is(e, ErrNoChanges) {
+			g.Trace(fmt.Sprintf("  %s: no changes", name))
+			continue
+		}
+		if e != nil {
+			g.Err(fmt.Sprintf("  %s failed with: %s", name, e))
+			if err == nil {
+				err = e
+			}
+			continue
+		}
+		g.Log(fmt.Sprintf("  %s: ok", name))
+	}
+	return
+}
+
+// Writes version to single source handling its VPrefix mode.
+func (g *SourceGroup) setSource(src SourceWithMeta, v semver.Version) error {
+	if t, err := parseTemplate(src.Format); src.Format != "" && err == nil {
+		if _, err := t.render(&v, g.VersionScheme()); err != nil {
+			return err
+		}
+	}
+	fs := g.SourceFS(g.setFS, src)
+	switch src.VPrefix {
+	case VPrefixTrue:
+		return src.Source.Set(*AddVPrefix(&v), fs)
+	case VPrefixFalse:
+		return src.Source.Set(*TrimVPrefix(&v), fs)
+	}
+	return src.Source.Set(v, fs)
+}
+
+func (g *SourceGroup) verify() error {
+	for name := range g.Sources {
+		if !IsSourceName(name) {
+			return fmt.Errorf("%s must be in CamelCase", name)
+		}
+	}
+	for name, src := range g.Sources {
+		p, err := parsePolicy(src.Policy)
+		if src.Policy == "" || err != nil || p.kind != policyAtLeast {
+			continue
+		}
+		if _, ok := g.Sources[p.source]; !ok || p.source == name {
+			return fmt.Errorf(
+				"%s policy refers to unknown source %s", name, p.source,
+			)
+		}
+	}
+	for group, names := range g.Groups {
+		for _, name := range names {
+			if _, ok := g.Sources[name]; !ok {
+				return fmt.Errorf("group %s refers to unknown source %s", group, name)
+			}
+		}
+	}
+	return nil
+}
+
+// Returns sources of named group.
+func (g *SourceGroup) GroupSources(group string) ([]Name, error) {
+	names, ok := g.Groups[group]
+	if !ok {
+		return nil, fmt.Errorf("unknown source group %s", group)
+	}
+	return names, nil
+}
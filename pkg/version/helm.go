@@ -0,0 +1,349 @@
+package version
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/asciimoth/rewrite"
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/goccy/go-yaml/token"
+)
+
+// Chart.yaml fields.
+const (
+	helmVersion    = "version"
+	helmAppVersion = "appVersion"
+)
+
+// Coupling rules between tracked and other Chart.yaml version fields.
+const (
+	HelmCouplingKeep  = "keep"
+	HelmCouplingSync  = "sync"
+	HelmCouplingMajor = "major"
+	HelmCouplingMinor = "minor"
+	HelmCouplingPatch = "patch"
+)
+
+func init() {
+	RegisterSource("helm", func() Source { return &HelmSource{} })
+	RegisterDefaultSource("HelmChart", SourceWithMeta{
+		VPrefix: VPrefixFalse,
+		Source: &HelmSource{
+			Path: "Chart.yaml",
+		},
+	}, "Chart.yaml")
+}
+
+// HelmSource handles `version` and `appVersion` of Chart.yaml.
+// Track selects field reported by Get and written by Set, Coupling selects
+// how the other field follows it:
+//   - keep  - other field stays untouched (default)
+//   - sync  - other field always equals to tracked one
+//   - major, minor, patch - other field is incremented every time
+//     tracked one changes
+//
+// Dependents are globs of Chart.yaml files of charts depending on this one;
+// their `dependencies[].version` requirements follow chart `version`.
+type HelmSource struct {
+	Path       string
+	Track      string
+	Coupling   string
+	Dependents []string
+}
+
+func (d *HelmSource) IsCanBeLesser() bool {
+	return false
+}
+
+func (d *HelmSource) IsReadOnly() bool {
+	return false
+}
+
+func (d *HelmSource) RequiredOptions() []string {
+	return []string{"Path"}
+}
+
+func (d *HelmSource) Files(fs FS) ([]string, error) {
+	return globFiles(fs, append([]string{d.Path}, d.Dependents...)...)
+}
+
+func (d *HelmSource) Get(fs FS) (*semver.Version, error) {
+	track, other, err := d.fields()
+	if err != nil {
+		return nil, err
+	}
+	files, err := fs.Glob(d.Path)
+	if err != nil {
+		return nil, err
+	}
+	var v *semver.Version
+	for _, file := range files {
+		chart, err := readChart(fs, file)
+		if err != nil {
+			return nil, err
+		}
+		val := chart.field(track)
+		if val == "" {
+			continue
+		}
+		cv, err := ParseVersion(fs, val)
+		if err != nil {
+			return nil, err
+		}
+		if d.Coupling == HelmCouplingSync && chart.field(other) != "" {
+			ov, err := ParseVersion(fs, chart.field(other))
+			if err != nil {
+				return nil, err
+			}
+			if !ov.Equal(cv) {
+				return nil, fmt.Errorf(
+					"%s: %s %s is not in sync with %s %s",
+					file, other, ov, track, cv,
+				)
+			}
+		}
+		if v != nil && !v.Equal(cv) {
+			return nil, errUnsync
+		}
+		v = cv
+	}
+	return v, nil
+}
+
+func (d *HelmSource) Set(v semver.Version, fs FS) error {
+	track, other, err := d.fields()
+	if err != nil {
+		return err
+	}
+	files, err := fs.Glob(d.Path)
+	if err != nil {
+		return err
+	}
+	val := FormatVersion(fs, &v)
+	changes := false
+	for _, file := range files {
+		chart, err := readChart(fs, file)
+		if err != nil {
+			return err
+		}
+		prev := chart.field(track)
+		if prev == "" {
+			continue
+		}
+		prevOther := chart.field(other)
+		err = chart.set(track, val)
+		if err != nil {
+			return err
+		}
+		err = d.couple(fs, chart, other, prev != val, val)
+		if err != nil {
+			return err
+		}
+		if prev != val || chart.field(other) != prevOther {
+			err = rewrite.Write(fs, file, chart.data)
+			if err != nil {
+				return err
+			}
+			changes = true
+		}
+		updated, err := d.updateDependents(
+			fs, chart.Name, chart.field(helmVersion),
+		)
+		if err != nil {
+			return err
+		}
+		changes = changes || updated
+	}
+	if changes {
+		return nil
+	}
+	return ErrNoChanges
+}
+
+// Returns tracked and other field names.
+func (d *HelmSource) fields() (string, string, error) {
+	switch {
+	case d.Track == "" || strings.EqualFold(d.Track, helmVersion):
+		return helmVersion, helmAppVersion, nil
+	case strings.EqualFold(d.Track, helmAppVersion):
+		return helmAppVersion, helmVersion, nil
+	}
+	return "", "", fmt.Errorf(
+		"unknown helm Track %q, expected %s or %s",
+		d.Track, helmVersion, helmAppVersion,
+	)
+}
+
+func (d *HelmSource) couple(
+	fs FS,
+	chart *helmChart,
+	other string,
+	changed bool,
+	val string,
+) error {
+	switch strings.ToLower(d.Coupling) {
+	case "", HelmCouplingKeep:
+		return nil
+	case HelmCouplingSync:
+		return chart.set(other, val)
+	}
+	if !changed || chart.field(other) == "" {
+		return nil
+	}
+	ov, err := ParseVersion(fs, chart.field(other))
+	if err != nil {
+		return err
+	}
+	var nv semver.Version
+	switch strings.ToLower(d.Coupling) {
+	case HelmCouplingMajor:
+		nv = ov.IncMajor()
+	case HelmCouplingMinor:
+		nv = ov.IncMinor()
+	case HelmCouplingPatch:
+		nv = ov.IncPatch()
+	default:
+		return fmt.Errorf("unknown helm Coupling %q", d.Coupling)
+	}
+	return chart.set(other, FormatVersion(fs, &nv))
+}
+
+func (d *HelmSource) updateDependents(
+	fs FS,
+	name, version string,
+) (bool, error) {
+	if name == "" || version == "" {
+		return false, nil
+	}
+	updated := false
+	for _, pattern := range d.Dependents {
+		files, err := fs.Glob(pattern)
+		if err != nil {
+			return false, err
+		}
+		for _, file := range files {
+			chart, err := readChart(fs, file)
+			if err != nil {
+				return false, err
+			}
+			changed := false
+			for i, dep := range chart.Dependencies {
+				if dep.Name != name {
+					continue
+				}
+				req, ok := updateRequirement(dep.Version, version)
+				if !ok {
+					continue
+				}
+				field := fmt.Sprintf("dependencies[%d].version", i)
+				err := chart.set(field, req)
+				if err != nil {
+					return false, err
+				}
+				changed = true
+			}
+			if !changed {
+				continue
+			}
+			err = rewrite.Write(fs, file, chart.data)
+			if err != nil {
+				return false, err
+			}
+			updated = true
+		}
+	}
+	return updated, nil
+}
+
+type helmChart struct {
+	Name         string `yaml:"name"`
+	Version      string `yaml:"version"`
+	AppVersion   string `yaml:"appVersion"`
+	Dependencies []struct {
+		Name    string `yaml:"name"`
+		Version string `yaml:"version"`
+	} `yaml:"dependencies"`
+	ast  *ast.File
+	data []byte
+}
+
+func readChart(fs FS, file string) (*helmChart, error) {
+	data, err := rewrite.Read(fs, file)
+	if err != nil {
+		return nil, err
+	}
+	var chart helmChart
+	if err := yaml.Unmarshal(data, &chart); err != nil {
+		return nil, err
+	}
+	chart.ast, err = parser.ParseBytes(data, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	chart.data = data
+	return &chart, nil
+}
+
+func (c *helmChart) field(name string) string {
+	if name == helmAppVersion {
+		return c.AppVersion
+	}
+	return c.Version
+}
+
+// Replaces existing scalar keeping its quoting style. Only bytes of the
+// scalar are changed, so comments and formatting of the file are kept.
+func (c *helmChart) set(field, val string) error {
+	p, err := yaml.PathString("$." + field)
+	if err != nil {
+		return err
+	}
+	node, err := p.FilterFile(c.ast)
+	if err != nil {
+		if errors.Is(err, yaml.ErrNotFoundNode) {
+			return nil
+		}
+		return err
+	}
+	tk := node.GetToken()
+	old, nval := tk.Value, val
+	switch tk.Type { //nolint:exhaustive
+	case token.SingleQuoteType:
+		old, nval = "'"+old+"'", "'"+val+"'"
+	case token.DoubleQuoteType:
+		old, nval = `"`+old+`"`, `"`+val+`"`
+	default:
+		// Keep value a string, e.g. `1.10` would be a float
+		if token.New(val, val, &token.Position{}).Type != token.StringType {
+			nval = `"` + val + `"`
+		}
+	}
+	lines := bytes.SplitAfter(c.data, []byte("\n"))
+	idx, col := tk.Position.Line-1, tk.Position.Column-1
+	if idx < 0 || idx >= len(lines) || col < 0 || col > len(lines[idx]) ||
+		!bytes.HasPrefix(lines[idx][col:], []byte(old)) {
+		return fmt.Errorf("can't locate %s value %s", field, old)
+	}
+	lines[idx] = slices.Concat(
+		lines[idx][:col], []byte(nval), lines[idx][col+len(old):],
+	)
+	data := bytes.Join(lines, nil)
+	file, err := parser.ParseBytes(data, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+	c.data, c.ast = data, file
+	switch field {
+	case helmVersion:
+		c.Version = val
+	case helmAppVersion:
+		c.AppVersion = val
+	}
+	return nil
+}
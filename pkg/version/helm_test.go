@@ -0,0 +1,95 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/asciimoth/rewrite"
+)
+
+func TestHelmSetKeepsFile(t *testing.T) {
+	tests := []struct {
+		name   string
+		src    HelmSource
+		chart  string
+		result string
+	}{
+		{
+			name:   "comments",
+			src:    HelmSource{Path: "Chart.yaml"},
+			chart:  "# Chart\nname: lib   # name\nversion: 0.1.0 # bumped by CI\n\nappVersion: '1.0'\n",
+			result: "# Chart\nname: lib   # name\nversion: 0.2.0 # bumped by CI\n\nappVersion: '1.0'\n",
+		},
+		{
+			name:   "quotes",
+			src:    HelmSource{Path: "Chart.yaml", Coupling: HelmCouplingSync},
+			chart:  "name: lib\nversion: '0.1.0'\nappVersion: \"0.1.0\"  # app\n",
+			result: "name: lib\nversion: '0.2.0'\nappVersion: \"0.2.0\"  # app\n",
+		},
+		{
+			name:   "flow",
+			src:    HelmSource{Path: "Chart.yaml", Track: helmAppVersion, Coupling: HelmCouplingMinor},
+			chart:  "{name: lib, version: 1.4.2, appVersion: 0.1.0}\n",
+			result: "{name: lib, version: 1.5.0, appVersion: 0.2.0}\n",
+		},
+	}
+	for _, tt := range tests {
+		fs := testFS(t, map[string]string{"Chart.yaml": tt.chart})
+		if err := tt.src.Set(*semver.MustParse("0.2.0"), fs); err != nil {
+			t.Errorf("%s: Set: %s", tt.name, err)
+			continue
+		}
+		data, err := rewrite.Read(fs, "Chart.yaml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tt.result {
+			t.Errorf("%s: Chart.yaml after Set:\n%s\nwant:\n%s", tt.name, data, tt.result)
+		}
+	}
+}
+
+func TestHelmSetDependents(t *testing.T) {
+	fs := testFS(t, map[string]string{
+		"lib/Chart.yaml": "name: lib\nversion: 1.2.0\n",
+		"app/Chart.yaml": "name: app\nversion: 0.1.0\ndependencies:\n" +
+			"  - name: lib\n    version: ~1.2.0 # pinned\n" +
+			"  - name: other\n    version: ^1.2.0\n",
+	})
+	src := &HelmSource{Path: "lib/Chart.yaml", Dependents: []string{"*/Chart.yaml"}}
+	if err := src.Set(*semver.MustParse("1.3.0"), fs); err != nil {
+		t.Fatal(err)
+	}
+	data, err := rewrite.Read(fs, "app/Chart.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "name: app\nversion: 0.1.0\ndependencies:\n" +
+		"  - name: lib\n    version: ~1.3.0 # pinned\n" +
+		"  - name: other\n    version: ^1.2.0\n"
+	if string(data) != want {
+		t.Errorf("dependent chart after Set:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+// Coupled field is read and written with scheme of the source.
+func TestHelmCouplingUsesScheme(t *testing.T) {
+	fs := &schemeFS{
+		testFS(t, map[string]string{
+			"Chart.yaml": "name: lib\nversion: 1.2.0\nappVersion: 3.1.0.post1\n",
+		}),
+		dialects["pep440"](&SemVerScheme{}),
+	}
+	src := &HelmSource{Path: "Chart.yaml", Coupling: HelmCouplingPatch}
+	if err := src.Set(*semver.MustParse("1.3.0-rc.1"), fs); err != nil {
+		t.Fatal(err)
+	}
+	data, err := rewrite.Read(fs, "Chart.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "name: lib\nversion: 1.3.0rc1\nappVersion: 3.1.1\n"
+	if string(data) != want {
+		t.Errorf("Chart.yaml after Set:\n%s\nwant:\n%s", data, want)
+	}
+}
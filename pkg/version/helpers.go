@@ -1,4 +1,4 @@
-package main
+package version
 
 import (
 	"errors"
@@ -12,12 +12,13 @@ import (
 )
 
 var (
-	errNoChanges = errors.New("no changes")
+	ErrNoChanges = errors.New("no changes")
 	errUnsync    = errors.New("multiple files reports different versions")
 )
 
-// true if the first rune is a letter and is uppercase.
-func startsWithCapital(s string) bool {
+// Reports whether s may be a source name,
+// i.e. its first rune is an uppercase letter.
+func IsSourceName(s string) bool {
 	if s == "" {
 		return false
 	}
@@ -25,29 +26,29 @@ func startsWithCapital(s string) bool {
 	return unicode.IsLetter(r) && unicode.IsUpper(r)
 }
 
-func hasVPrefix(v *semver.Version) bool {
+func HasVPrefix(v *semver.Version) bool {
 	if v == nil {
 		return false
 	}
 	return strings.HasPrefix(v.Original(), "v")
 }
 
-func addVPrefix(v *semver.Version) *semver.Version {
-	if hasVPrefix(v) {
+func AddVPrefix(v *semver.Version) *semver.Version {
+	if HasVPrefix(v) {
 		return v
 	}
 	return semver.MustParse("v" + v.String())
 }
 
-func trimVPrefix(v *semver.Version) *semver.Version {
-	if !hasVPrefix(v) {
+func TrimVPrefix(v *semver.Version) *semver.Version {
+	if !HasVPrefix(v) {
 		return v
 	}
 	return semver.MustParse(strings.TrimLeft(v.String(), "v"))
 }
 
 func verToString(version *semver.Version) string {
-	if hasVPrefix(version) {
+	if HasVPrefix(version) {
 		return "v" + version.String()
 	}
 	return version.String()
@@ -78,7 +79,7 @@ func getFromDoc(
 		}
 		val := doc.Get(kp)
 		if val != "" {
-			cv, err := semver.NewVersion(val)
+			cv, err := ParseVersion(fs, val)
 			if err != nil {
 				return nil, err
 			}
@@ -105,7 +106,7 @@ func setToDoc(
 	if err != nil {
 		return err
 	}
-	val := verToString(&v)
+	val := FormatVersion(fs, &v)
 	changes := false
 	for _, path := range files {
 		bytes, err := rewrite.Read(fs, path)
@@ -117,7 +118,7 @@ func setToDoc(
 			continue
 		}
 		prev := doc.Get(kp)
-		if prev == "" {
+		if prev == "" || prev == val {
 			continue
 		}
 		err = doc.Set(kp, val)
@@ -134,5 +135,5 @@ func setToDoc(
 	if changes {
 		return nil
 	}
-	return errNoChanges
+	return ErrNoChanges
 }
@@ -1,4 +1,4 @@
-package main
+package version
 
 import (
 	"github.com/Masterminds/semver/v3"
@@ -13,7 +13,21 @@ func init() {
 			"package.json",
 			[]string{"version"},
 		},
-	})
+	}, "package.json")
+	RegisterDefaultSource("Composer", SourceWithMeta{
+		VPrefix: VPrefixFalse,
+		Source: &JSONSource{
+			"composer.json",
+			[]string{"version"},
+		},
+	}, "composer.json")
+	RegisterDefaultSource("WebManifest", SourceWithMeta{
+		VPrefix: VPrefixFalse,
+		Source: &JSONSource{
+			"manifest.json",
+			[]string{"version"},
+		},
+	}, "manifest.json")
 }
 
 type JSONSource struct {
@@ -29,6 +43,14 @@ func (d *JSONSource) IsReadOnly() bool {
 	return false
 }
 
+func (d *JSONSource) RequiredOptions() []string {
+	return []string{"Path", "KeyPath"}
+}
+
+func (d *JSONSource) Files(fs FS) ([]string, error) {
+	return globFiles(fs, d.Path)
+}
+
 func (d *JSONSource) Get(fs FS) (*semver.Version, error) {
 	return getFromDoc(fs, json.New, d.KeyPath, d.Path)
 }
@@ -0,0 +1,138 @@
+package version
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// URL config JSON Schema is published at.
+const ConfigSchemaURL = "https://raw.githubusercontent.com/asciimoth/version/main/schema/version.schema.json"
+
+// ConfigSchema returns JSON Schema of config with all registered source
+// types and schemes.
+// Keys are listed in canonical case while config keys are
+// case-insensitive.
+func ConfigSchema() map[string]any {
+	stringList := map[string]any{
+		"type":  "array",
+		"items": map[string]any{"type": "string"},
+	}
+	refs := map[string]any{
+		"oneOf": []any{map[string]any{"type": "string"}, stringList},
+	}
+	srcs := []any{}
+	for _, typ := range slices.Sorted(maps.Keys(sources)) {
+		srcs = append(srcs, sourceSchema(typ, sources[typ]()))
+	}
+	schemeTables := []any{}
+	for _, typ := range slices.Sorted(maps.Keys(schemes)) {
+		props := map[string]any{typeKey: map[string]any{"const": typ}}
+		optionsSchema(props, reflect.TypeOf(schemes[typ]()))
+		schemeTables = append(schemeTables, map[string]any{
+			"type":                 "object",
+			"properties":           props,
+			"required":             []any{typeKey},
+			"additionalProperties": false,
+		})
+	}
+	return map[string]any{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"$id":                  ConfigSchemaURL,
+		"title":                "version config",
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties": map[string]any{
+			jsonSchemaKey:     map[string]any{"type": "string"},
+			defaultVersionKey: map[string]any{"type": "string"},
+			schemeKey: map[string]any{
+				"oneOf": append(
+					[]any{map[string]any{"enum": sortedNames(schemes)}},
+					schemeTables...,
+				),
+			},
+			strictKey:        map[string]any{"type": "boolean"},
+			ignoredFilesKey:  stringList,
+			readOnlyFilesKey: stringList,
+			groupsKey: map[string]any{
+				"type":                 "object",
+				"additionalProperties": stringList,
+			},
+			extendsKey: refs,
+			includeKey: refs,
+			sourcesKey: map[string]any{
+				"type":                 "object",
+				"additionalProperties": map[string]any{"oneOf": srcs},
+			},
+		},
+	}
+}
+
+func sourceSchema(typ string, src Source) map[string]any {
+	props := map[string]any{
+		typeKey: map[string]any{"const": typ},
+		"VPrefix": map[string]any{
+			"oneOf": []any{
+				map[string]any{"type": "string"},
+				map[string]any{"type": "boolean"},
+				map[string]any{"type": "integer"},
+			},
+		},
+		"Disabled": map[string]any{"type": "boolean"},
+		"Dialect":  map[string]any{"enum": sortedNames(dialects)},
+		"Format":   map[string]any{"type": "string"},
+		"Policy":   map[string]any{"type": "string"},
+		"Priority": map[string]any{"type": "integer"},
+	}
+	optionsSchema(props, reflect.TypeOf(src))
+	required := []any{typeKey}
+	if req, ok := src.(RequiredOptionsSource); ok {
+		for _, opt := range req.RequiredOptions() {
+			required = append(required, opt)
+		}
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           props,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// Adds schemas of options of struct type to props.
+func optionsSchema(props map[string]any, typ reflect.Type) {
+	for _, f := range optionFields(typ) {
+		props[f.name] = typeSchema(f.typ)
+	}
+}
+
+func typeSchema(typ reflect.Type) map[string]any {
+	switch typ.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": typeSchema(typ.Elem())}
+	case reflect.Map:
+		return map[string]any{
+			"type":                 "object",
+			"additionalProperties": typeSchema(typ.Elem()),
+		}
+	}
+	return map[string]any{}
+}
+
+// Returns sorted keys of registry as JSON Schema enum.
+func sortedNames[T any](registry map[string]T) []any {
+	names := []any{}
+	for _, name := range slices.Sorted(maps.Keys(registry)) {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,447 @@
+package version
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/asciimoth/inplace"
+	"github.com/asciimoth/inplace/json"
+	"github.com/asciimoth/inplace/toml"
+	"github.com/asciimoth/rewrite"
+	"github.com/creachadair/tomledit"
+	"github.com/creachadair/tomledit/parser"
+)
+
+var pyNameNormaliserExp = regexp.MustCompile(`[-_.]+`)
+
+func init() {
+	RegisterSource("npmlock", func() Source { return &NpmLockSource{} })
+	RegisterSource("cargolock", func() Source { return &CargoLockSource{} })
+	RegisterSource("pylock", func() Source { return &PyLockSource{} })
+	RegisterDefaultSource("PackageLock", SourceWithMeta{
+		VPrefix: VPrefixFalse,
+		Source: &NpmLockSource{
+			"package-lock.json",
+		},
+	}, "package-lock.json")
+	RegisterDefaultSource("CargoLock", SourceWithMeta{
+		VPrefix: VPrefixFalse,
+		Source: &CargoLockSource{
+			"Cargo.lock",
+			"Cargo.toml",
+			nil,
+		},
+	}, "Cargo.lock")
+	RegisterDefaultSource("PoetryLock", SourceWithMeta{
+		VPrefix: VPrefixFalse,
+		Dialect: "pep440",
+		Source: &PyLockSource{
+			"poetry.lock",
+			"pyproject.toml",
+			nil,
+		},
+	}, "poetry.lock")
+}
+
+// NpmLockSource keeps root `version` and `packages[""].version` of
+// package-lock.json (or npm-shrinkwrap.json) in sync with package.json.
+type NpmLockSource struct {
+	Path string
+}
+
+func (d *NpmLockSource) IsCanBeLesser() bool {
+	return false
+}
+
+func (d *NpmLockSource) IsReadOnly() bool {
+	return false
+}
+
+func (d *NpmLockSource) RequiredOptions() []string {
+	return []string{"Path"}
+}
+
+func (d *NpmLockSource) Files(fs FS) ([]string, error) {
+	return globFiles(fs, d.Path)
+}
+
+func (d *NpmLockSource) Get(fs FS) (*semver.Version, error) {
+	var v *semver.Version
+	for _, kp := range npmLockKeyPaths() {
+		cv, err := getFromDoc(fs, json.New, kp, d.Path)
+		if err != nil {
+			return nil, err
+		}
+		if cv == nil {
+			continue
+		}
+		if v != nil && !v.Equal(cv) {
+			return nil, errUnsync
+		}
+		v = cv
+	}
+	return v, nil
+}
+
+func (d *NpmLockSource) Set(v semver.Version, fs FS) error {
+	changes := false
+	for _, kp := range npmLockKeyPaths() {
+		err := setToDoc(v, fs, json.NewHuJSON, kp, d.Path)
+		if errors.Is(err, ErrNoChanges) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		changes = true
+	}
+	if changes {
+		return nil
+	}
+	return ErrNoChanges
+}
+
+func npmLockKeyPaths() []inplace.KeyPath {
+	return []inplace.KeyPath{
+		{"version"},
+		{"packages", "", "version"},
+	}
+}
+
+// CargoLockSource keeps `[[package]]` entries of Cargo.lock for the crates
+// of the project itself in sync with Cargo.toml.
+// Crate names are taken from Names or, if empty, from Manifest and
+// manifests of its workspace members.
+type CargoLockSource struct {
+	Path     string
+	Manifest string
+	Names    []string
+}
+
+func (d *CargoLockSource) IsCanBeLesser() bool {
+	return false
+}
+
+func (d *CargoLockSource) IsReadOnly() bool {
+	return false
+}
+
+func (d *CargoLockSource) RequiredOptions() []string {
+	return []string{"Path"}
+}
+
+func (d *CargoLockSource) Files(fs FS) ([]string, error) {
+	return globFiles(fs, d.Path, d.Manifest)
+}
+
+func (d *CargoLockSource) Get(fs FS) (*semver.Version, error) {
+	names, err := d.names(fs)
+	if err != nil {
+		return nil, err
+	}
+	return getFromLock(fs, d.Path, names)
+}
+
+func (d *CargoLockSource) Set(v semver.Version, fs FS) error {
+	names, err := d.names(fs)
+	if err != nil {
+		return err
+	}
+	return setToLock(v, fs, d.Path, names)
+}
+
+func (d *CargoLockSource) names(fs FS) ([]string, error) {
+	if len(d.Names) > 0 {
+		return d.Names, nil
+	}
+	if d.Manifest == "" {
+		return []string{}, nil
+	}
+	// Workspace members are listed in the same Cargo.lock
+	manifests, err := cargoWorkspaceManifests(fs, d.Manifest)
+	if err != nil {
+		return nil, err
+	}
+	return namesFromManifests(fs, manifests)
+}
+
+// PyLockSource keeps `[[package]]` entries of poetry.lock or uv.lock for the
+// project itself in sync with pyproject.toml.
+// Package names are taken from Names or, if empty, from Manifest.
+// Note that poetry.lock usually contains only dependencies, so it reports
+// version only for path dependencies listed in Names.
+type PyLockSource struct {
+	Path     string
+	Manifest string
+	Names    []string
+}
+
+func (d *PyLockSource) IsCanBeLesser() bool {
+	return false
+}
+
+func (d *PyLockSource) IsReadOnly() bool {
+	return false
+}
+
+func (d *PyLockSource) RequiredOptions() []string {
+	return []string{"Path"}
+}
+
+func (d *PyLockSource) Files(fs FS) ([]string, error) {
+	return globFiles(fs, d.Path, d.Manifest)
+}
+
+func (d *PyLockSource) Get(fs FS) (*semver.Version, error) {
+	names, err := d.names(fs)
+	if err != nil {
+		return nil, err
+	}
+	return getFromLock(fs, d.Path, names)
+}
+
+func (d *PyLockSource) Set(v semver.Version, fs FS) error {
+	names, err := d.names(fs)
+	if err != nil {
+		return err
+	}
+	return setToLock(v, fs, d.Path, names)
+}
+
+func (d *PyLockSource) names(fs FS) ([]string, error) {
+	names := d.Names
+	if len(names) == 0 {
+		var err error
+		names, err = namesFromManifest(fs, d.Manifest, []inplace.KeyPath{
+			{"project", "name"},
+			{"tool", "poetry", "name"},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	normalised := make([]string, 0, len(names))
+	for _, name := range names {
+		normalised = append(normalised, normalisePyName(name))
+	}
+	return normalised, nil
+}
+
+// PEP 503 name normalisation.
+func normalisePyName(name string) string {
+	return strings.ToLower(pyNameNormaliserExp.ReplaceAllString(name, "-"))
+}
+
+// Collects values of the first non-empty key path from each manifest
+// matched by path glob.
+func namesFromManifest(
+	fs FS,
+	path string,
+	kps []inplace.KeyPath,
+) ([]string, error) {
+	names := []string{}
+	if path == "" {
+		return names, nil
+	}
+	files, err := fs.Glob(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		data, err := rewrite.Read(fs, file)
+		if err != nil {
+			continue
+		}
+		doc, err := toml.New(data)
+		if err != nil {
+			return nil, err
+		}
+		for _, kp := range kps {
+			if name := doc.Get(kp); name != "" {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	return names, nil
+}
+
+// Version entry of `[[package]]` table in TOML lockfile.
+type lockEntry struct {
+	line  int // 1-based
+	value string
+}
+
+// Finds version entries of `[[package]]` tables with one of provided names.
+func findLockEntries(data []byte, names []string) ([]lockEntry, error) {
+	doc, err := tomledit.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	entries := []lockEntry{}
+	for _, sec := range doc.Sections {
+		if sec.Heading == nil || !sec.IsArray ||
+			!sec.Name.Equals(parser.Key{"package"}) {
+			continue
+		}
+		var name string
+		var version *parser.KeyValue
+		for _, item := range sec.Items {
+			kv, ok := item.(*parser.KeyValue)
+			if !ok || len(kv.Name) != 1 {
+				continue
+			}
+			switch kv.Name[0] {
+			case "name":
+				name, _ = tomlUnquote(kv.Value)
+			case "version":
+				version = kv
+			}
+		}
+		if version == nil || !lockNameMatches(name, names) {
+			continue
+		}
+		val, ok := tomlUnquote(version.Value)
+		if !ok {
+			continue
+		}
+		entries = append(entries, lockEntry{version.Line, val})
+	}
+	return entries, nil
+}
+
+func lockNameMatches(name string, names []string) bool {
+	for _, n := range names {
+		if n == name || normalisePyName(n) == normalisePyName(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func getFromLock(fs FS, path string, names []string) (*semver.Version, error) {
+	if len(names) == 0 {
+		return nil, nil //nolint:nilnil
+	}
+	files, err := fs.Glob(path)
+	if err != nil {
+		return nil, err
+	}
+	var v *semver.Version
+	for _, file := range files {
+		data, err := rewrite.Read(fs, file)
+		if err != nil {
+			continue
+		}
+		entries, err := findLockEntries(data, names)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			cv, err := ParseVersion(fs, entry.value)
+			if err != nil {
+				return nil, err
+			}
+			if v == nil {
+				v = cv
+				continue
+			}
+			if !cv.Equal(v) {
+				return nil, errUnsync
+			}
+		}
+	}
+	return v, nil
+}
+
+// Rewrites only lines with version entries to keep the rest of lockfile
+// byte-for-byte unchanged.
+func setToLock(v semver.Version, fs FS, path string, names []string) error {
+	if len(names) == 0 {
+		return ErrNoChanges
+	}
+	files, err := fs.Glob(path)
+	if err != nil {
+		return err
+	}
+	val := FormatVersion(fs, &v)
+	changes := false
+	for _, file := range files {
+		data, err := rewrite.Read(fs, file)
+		if err != nil {
+			continue
+		}
+		entries, err := findLockEntries(data, names)
+		if err != nil {
+			return err
+		}
+		lines := bytes.SplitAfter(data, []byte("\n"))
+		changed := false
+		for _, entry := range entries {
+			if entry.value == val {
+				continue
+			}
+			if replaceTomlValue(lines, entry.line, "version", entry.value, val) {
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		err = rewrite.Write(fs, file, bytes.Join(lines, nil))
+		if err != nil {
+			return err
+		}
+		changes = true
+	}
+	if changes {
+		return nil
+	}
+	return ErrNoChanges
+}
+
+// Returns value of TOML string literal.
+func tomlUnquote(v parser.Value) (string, bool) {
+	str := v.String()
+	if len(str) < 2 {
+		return "", false
+	}
+	if str[0] == '\'' && str[len(str)-1] == '\'' {
+		return str[1 : len(str)-1], true
+	}
+	if str[0] != '"' {
+		return "", false
+	}
+	val, err := strconv.Unquote(str)
+	if err != nil {
+		return "", false
+	}
+	return val, true
+}
+
+// Replaces first quoted old value after the key on 1-based line of
+// document split by lines. Reports whether line was changed.
+func replaceTomlValue(lines [][]byte, line int, key, old, val string) bool {
+	idx := line - 1
+	if idx < 0 || idx >= len(lines) {
+		return false
+	}
+	src := string(lines[idx])
+	start := max(strings.Index(src, key), 0)
+	for _, quote := range []string{`"`, "'"} {
+		pos := strings.Index(src[start:], quote+old+quote)
+		if pos < 0 {
+			continue
+		}
+		pos += start
+		lines[idx] = []byte(
+			src[:pos] + quote + val + quote + src[pos+len(old)+2:],
+		)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package version
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/asciimoth/rewrite"
+)
+
+func TestLockSources(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     Source
+		dialect string
+		files   map[string]string
+		get     string
+		file    string
+		result  string
+	}{
+		{
+			name: "npmlock",
+			src:  &NpmLockSource{Path: "package-lock.json"},
+			files: map[string]string{
+				"package-lock.json": `{"name": "a", "version": "1.2.0", "packages": {` +
+					`"": {"name": "a", "version": "1.2.0"}, ` +
+					`"node_modules/b": {"version": "1.2.0"}}}` + "\n",
+			},
+			get:  "1.2.0",
+			file: "package-lock.json",
+			result: `{"name": "a", "version": "1.3.0", "packages": {` +
+				`"": {"name": "a", "version": "1.3.0"}, ` +
+				`"node_modules/b": {"version": "1.2.0"}}}` + "\n",
+		},
+		{
+			name: "cargolock manifest",
+			src:  &CargoLockSource{Path: "Cargo.lock", Manifest: "Cargo.toml"},
+			files: map[string]string{
+				"Cargo.toml": "[package]\nname = \"a\"\nversion = \"1.2.0\"\n",
+				"Cargo.lock": "# generated\nversion = 3\n\n" +
+					"[[package]]\nname = \"a\"\nversion = \"1.2.0\"\n\n" +
+					"[[package]]\nname = \"b\"\nversion = \"1.2.0\"\n",
+			},
+			get:  "1.2.0",
+			file: "Cargo.lock",
+			result: "# generated\nversion = 3\n\n" +
+				"[[package]]\nname = \"a\"\nversion = \"1.3.0\"\n\n" +
+				"[[package]]\nname = \"b\"\nversion = \"1.2.0\"\n",
+		},
+		{
+			name: "cargolock names",
+			src:  &CargoLockSource{Path: "Cargo.lock", Names: []string{"b"}},
+			files: map[string]string{
+				"Cargo.lock": "[[package]]\nname = \"a\"\nversion = '0.1.0'\n\n" +
+					"[[package]]\nname = \"b\"\nversion = '1.2.0' # b\n",
+			},
+			get:  "1.2.0",
+			file: "Cargo.lock",
+			result: "[[package]]\nname = \"a\"\nversion = '0.1.0'\n\n" +
+				"[[package]]\nname = \"b\"\nversion = '1.3.0' # b\n",
+		},
+		{
+			name:    "pylock",
+			src:     &PyLockSource{Path: "uv.lock", Manifest: "pyproject.toml"},
+			dialect: "pep440",
+			files: map[string]string{
+				"pyproject.toml": "[project]\nname = \"My_Pkg\"\n",
+				"uv.lock": "[[package]]\nname = \"my-pkg\"\nversion = \"1.2.0.post1\"\n" +
+					"source = { editable = \".\" }\n",
+			},
+			get:  "1.2.0.post1",
+			file: "uv.lock",
+			result: "[[package]]\nname = \"my-pkg\"\nversion = \"1.3.0\"\n" +
+				"source = { editable = \".\" }\n",
+		},
+	}
+	for _, tt := range tests {
+		var fs FS = testFS(t, tt.files)
+		if tt.dialect != "" {
+			fs = &schemeFS{fs, dialects[tt.dialect](&SemVerScheme{})}
+		}
+		v, err := tt.src.Get(fs)
+		if err != nil || v == nil {
+			t.Errorf("%s: Get = %v, %v", tt.name, v, err)
+			continue
+		}
+		if got := FormatVersion(fs, v); got != tt.get {
+			t.Errorf("%s: Get = %s, want %s", tt.name, got, tt.get)
+		}
+		// Writing current version changes nothing
+		if err := tt.src.Set(*v, fs); !errors.Is(err, ErrNoChanges) {
+			t.Errorf("%s: Set of current version: %v, want ErrNoChanges", tt.name, err)
+		}
+		if err := tt.src.Set(*semver.MustParse("1.3.0"), fs); err != nil {
+			t.Errorf("%s: Set: %s", tt.name, err)
+			continue
+		}
+		data, err := rewrite.Read(fs, tt.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tt.result {
+			t.Errorf("%s: %s after Set:\n%s\nwant:\n%s", tt.name, tt.file, data, tt.result)
+		}
+		v, err = tt.src.Get(fs)
+		if err != nil || v == nil || v.String() != "1.3.0" {
+			t.Errorf("%s: Get after Set = %v, %v, want 1.3.0", tt.name, v, err)
+		}
+	}
+}
+
+func TestLockSourceUnsync(t *testing.T) {
+	fs := testFS(t, map[string]string{
+		"Cargo.lock": "[[package]]\nname = \"a\"\nversion = \"1.2.0\"\n\n" +
+			"[[package]]\nname = \"b\"\nversion = \"1.3.0\"\n",
+	})
+	src := &CargoLockSource{Path: "Cargo.lock", Names: []string{"a", "b"}}
+	if _, err := src.Get(fs); !errors.Is(err, errUnsync) {
+		t.Errorf("Get of unsynced entries: %v, want %v", err, errUnsync)
+	}
+	// Lockfile without own package is left as is
+	src = &CargoLockSource{Path: "Cargo.lock", Names: []string{"c"}}
+	if err := src.Set(*semver.MustParse("2.0.0"), fs); !errors.Is(err, ErrNoChanges) {
+		t.Errorf("Set without matching entries: %v, want ErrNoChanges", err)
+	}
+}
@@ -0,0 +1,542 @@
+package version
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/asciimoth/rewrite"
+)
+
+type nixTokenKind int
+
+const (
+	nixIdent nixTokenKind = iota
+	nixString
+	nixPath
+	nixPunct
+	nixOther
+)
+
+func init() {
+	RegisterSource("nix", func() Source { return &NixSource{} })
+}
+
+// NixSource handles string attribute of Nix expression located by KeyPath,
+// e.g. `["packages", "default", "version"]` or name of a `let` binding.
+// KeyPath is matched against the tail of binding paths, where the path of a
+// binding is the chain of names of all enclosing bindings (function
+// applications and lambdas are looked through), so for
+//
+//	outputs = { ... }: let app = mkDerivation { version = "1.2.3"; }; in {
+//	  packages.default = app;
+//	};
+//
+// both `["app", "version"]` and `["packages", "default", "version"]`
+// (references to bindings are followed) point to the same value.
+// If the value is `builtins.readFile ./FILE` or `lib.fileContents ./FILE`,
+// content of FILE is used instead.
+type NixSource struct {
+	Path    string
+	KeyPath []string
+}
+
+func (d *NixSource) IsCanBeLesser() bool {
+	return false
+}
+
+func (d *NixSource) IsReadOnly() bool {
+	return false
+}
+
+func (d *NixSource) RequiredOptions() []string {
+	return []string{"Path", "KeyPath"}
+}
+
+// Returns matched files and files version is read from by them,
+// e.g. `builtins.readFile ./VERSION`.
+func (d *NixSource) Files(fs FS) ([]string, error) {
+	files, err := globFiles(fs, d.Path)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		val, err := d.locate(fs, file)
+		if err != nil || val == nil {
+			continue
+		}
+		files = appendUnique(files, val.file)
+	}
+	return files, nil
+}
+
+func (d *NixSource) Get(fs FS) (*semver.Version, error) {
+	files, err := fs.Glob(d.Path)
+	if err != nil {
+		return nil, err
+	}
+	var v *semver.Version
+	for _, file := range files {
+		val, err := d.locate(fs, file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if val == nil {
+			continue
+		}
+		cv, err := ParseVersion(fs, val.value)
+		if err != nil {
+			return nil, err
+		}
+		if v != nil && !v.Equal(cv) {
+			return nil, errUnsync
+		}
+		v = cv
+	}
+	return v, nil
+}
+
+func (d *NixSource) Set(v semver.Version, fs FS) error {
+	files, err := fs.Glob(d.Path)
+	if err != nil {
+		return err
+	}
+	changes := false
+	for _, file := range files {
+		val, err := d.locate(fs, file)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		nval := FormatVersion(fs, &v)
+		if val == nil || val.value == nval {
+			continue
+		}
+		err = val.set(fs, nval)
+		if err != nil {
+			return err
+		}
+		changes = true
+	}
+	if changes {
+		return nil
+	}
+	return ErrNoChanges
+}
+
+// Returns nil if there is no such attribute.
+func (d *NixSource) locate(fs FS, file string) (*nixValue, error) {
+	if len(d.KeyPath) == 0 {
+		return nil, errors.New("no KeyPath specified")
+	}
+	src, err := rewrite.Read(fs, file)
+	if err != nil {
+		return nil, err
+	}
+	toks, err := lexNix(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &nixParser{toks: toks}
+	p.parseExpr(0, nil)
+	b, err := p.resolve(d.KeyPath, 0)
+	if b == nil || err != nil {
+		return nil, err
+	}
+	return nixValueOf(fs, file, src, b)
+}
+
+// Located string value.
+type nixValue struct {
+	file       string
+	src        []byte
+	start, end int // Position of value in src
+	value      string
+}
+
+func nixValueOf(fs FS, file string, src []byte, b *nixBinding) (
+	*nixValue,
+	error,
+) {
+	val := b.value
+	// builtins.readFile ./VERSION
+	if len(val) >= 2 && val[len(val)-1].kind == nixPath &&
+		slices.Contains(
+			[]string{"readFile", "fileContents"},
+			val[len(val)-2].text,
+		) {
+		ref := path.Join(path.Dir(file), val[len(val)-1].text)
+		data, err := rewrite.Read(fs, ref)
+		if err != nil {
+			return nil, err
+		}
+		str := strings.TrimRight(string(data), "\r\n")
+		return &nixValue{ref, data, 0, len(str), str}, nil
+	}
+	if len(val) != 1 || val[0].kind != nixString || val[0].interpolated {
+		return nil, fmt.Errorf(
+			"%s is not a string literal",
+			strings.Join(b.path, "."),
+		)
+	}
+	tok := val[0]
+	quote := 1
+	if strings.HasPrefix(tok.text, "''") {
+		quote = 2
+	}
+	return &nixValue{
+		file,
+		src,
+		tok.start + quote,
+		tok.end - quote,
+		tok.text[quote : len(tok.text)-quote],
+	}, nil
+}
+
+// Writes new value keeping the rest of file unchanged.
+func (v *nixValue) set(fs FS, val string) error {
+	data := slices.Concat(v.src[:v.start], []byte(val), v.src[v.end:])
+	return rewrite.Write(fs, v.file, data)
+}
+
+type nixToken struct {
+	kind         nixTokenKind
+	text         string
+	start, end   int
+	interpolated bool
+}
+
+type nixBinding struct {
+	path  []string
+	value []nixToken
+}
+
+// Minimal parser that collects bindings of attribute sets and let blocks.
+type nixParser struct {
+	toks     []nixToken
+	bindings []nixBinding
+}
+
+func (p *nixParser) is(i int, texts ...string) bool {
+	return i < len(p.toks) && p.toks[i].kind != nixString &&
+		slices.Contains(texts, p.toks[i].text)
+}
+
+// Skips expression, returns index of terminating token
+// (`;`, closing bracket, `in` or end of input).
+func (p *nixParser) parseExpr(i int, prefix []string) int {
+	for i < len(p.toks) {
+		switch {
+		case p.is(i, ";", "}", "]", ")", "in"):
+			return i
+		case p.is(i, "{"):
+			i = p.parseBindings(i+1, prefix)
+			if p.is(i, "}") {
+				i++
+			}
+		case p.is(i, "let"):
+			i = p.parseBindings(i+1, prefix)
+			if p.is(i, "in") {
+				i++
+			}
+		case p.is(i, "[", "("):
+			i = p.parseExpr(i+1, prefix) + 1
+		default:
+			i++
+		}
+	}
+	return i
+}
+
+// Collects bindings, returns index of terminating `}` or `in`.
+func (p *nixParser) parseBindings(i int, prefix []string) int {
+	for i < len(p.toks) {
+		if p.is(i, "}", "in") {
+			return i
+		}
+		if p.is(i, "]", ")") {
+			i++
+			continue
+		}
+		if p.is(i, "inherit") {
+			for i < len(p.toks) && !p.is(i, ";") {
+				i++
+			}
+			i++
+			continue
+		}
+		attrs, j := p.parseAttrPath(i)
+		if attrs == nil {
+			// Not a binding, e.g. lambda formals `{ self, ... }:`
+			i = p.parseExpr(i, prefix)
+			if p.is(i, ";", ",") {
+				i++
+			}
+			continue
+		}
+		bpath := slices.Concat(prefix, attrs)
+		end := p.parseExpr(j, bpath)
+		p.bindings = append(p.bindings, nixBinding{
+			bpath,
+			p.toks[j:min(end, len(p.toks))],
+		})
+		i = end
+		if p.is(i, ";") {
+			i++
+		}
+	}
+	return i
+}
+
+// Parses `a."b".c =` returning attr names and index after `=`.
+func (p *nixParser) parseAttrPath(i int) ([]string, int) {
+	attrs := []string{}
+	for i < len(p.toks) {
+		tok := p.toks[i]
+		switch {
+		case tok.kind == nixIdent:
+			attrs = append(attrs, tok.text)
+		case tok.kind == nixString && !tok.interpolated &&
+			!strings.HasPrefix(tok.text, "''"):
+			attrs = append(attrs, tok.text[1:len(tok.text)-1])
+		default:
+			return nil, i
+		}
+		i++
+		if p.is(i, "=") {
+			return attrs, i + 1
+		}
+		if !p.is(i, ".") {
+			return nil, i
+		}
+		i++
+	}
+	return nil, i
+}
+
+// Finds binding with path ending with kp following references to other
+// bindings, e.g. `packages.default = app;`.
+func (p *nixParser) resolve(kp []string, depth int) (*nixBinding, error) {
+	if depth > len(p.bindings) {
+		return nil, errors.New("reference loop")
+	}
+	if b, err := p.find(kp); b != nil || err != nil {
+		return b, err
+	}
+	for k := len(kp) - 1; k > 0; k-- {
+		b, err := p.find(kp[:k])
+		if err != nil {
+			return nil, err
+		}
+		if b == nil {
+			continue
+		}
+		ref := b.reference()
+		if ref == nil {
+			return nil, nil //nolint:nilnil
+		}
+		return p.resolve(slices.Concat(ref, kp[k:]), depth+1)
+	}
+	return nil, nil //nolint:nilnil
+}
+
+// Exact match of the whole path wins over matches of its tail.
+func (p *nixParser) find(kp []string) (*nixBinding, error) {
+	for i, b := range p.bindings {
+		if slices.Equal(b.path, kp) {
+			return &p.bindings[i], nil
+		}
+	}
+	var found *nixBinding
+	for i, b := range p.bindings {
+		if len(b.path) < len(kp) ||
+			!slices.Equal(b.path[len(b.path)-len(kp):], kp) {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf(
+				"attribute %s is ambiguous: %s and %s",
+				strings.Join(kp, "."),
+				strings.Join(found.path, "."),
+				strings.Join(b.path, "."),
+			)
+		}
+		found = &p.bindings[i]
+	}
+	return found, nil
+}
+
+// Returns referenced attr path if binding value is a bare reference
+// like `app` or `self.app`.
+func (b *nixBinding) reference() []string {
+	ref := []string{}
+	for i, tok := range b.value {
+		if i%2 == 1 {
+			if tok.kind != nixPunct || tok.text != "." {
+				return nil
+			}
+			continue
+		}
+		if tok.kind != nixIdent {
+			return nil
+		}
+		ref = append(ref, tok.text)
+	}
+	if len(ref) == 0 || len(b.value)%2 == 0 {
+		return nil
+	}
+	return ref
+}
+
+func isNixIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNixIdentChar(c byte) bool {
+	return isNixIdentStart(c) || (c >= '0' && c <= '9') || c == '\'' ||
+		c == '-'
+}
+
+func isNixPathChar(c byte) bool {
+	return isNixIdentChar(c) || c == '.' || c == '/' || c == '+'
+}
+
+func lexNix(src []byte) ([]nixToken, error) {
+	toks := []nixToken{}
+	i := 0
+	for i < len(src) {
+		tok, next, err := nextNixToken(src, i)
+		if err != nil {
+			return nil, err
+		}
+		if tok != nil {
+			toks = append(toks, *tok)
+		}
+		i = next
+	}
+	return toks, nil
+}
+
+// Returns nil token for whitespace and comments.
+func nextNixToken(src []byte, i int) (*nixToken, int, error) {
+	c := src[i]
+	rest := src[i:]
+	start := i
+	tok := func(kind nixTokenKind, end int) (*nixToken, int, error) {
+		return &nixToken{kind, string(src[start:end]), start, end, false},
+			end, nil
+	}
+	switch {
+	case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		return nil, i + 1, nil
+	case c == '#':
+		for i < len(src) && src[i] != '\n' {
+			i++
+		}
+		return nil, i, nil
+	case bytes.HasPrefix(rest, []byte("/*")):
+		end := bytes.Index(src[i+2:], []byte("*/"))
+		if end < 0 {
+			return nil, 0, errors.New("unterminated comment")
+		}
+		return nil, i + 2 + end + 2, nil
+	case c == '"' || bytes.HasPrefix(rest, []byte("''")):
+		return lexNixString(src, i)
+	case bytes.HasPrefix(rest, []byte("./")) ||
+		bytes.HasPrefix(rest, []byte("../")) ||
+		bytes.HasPrefix(rest, []byte("~/")):
+		for i < len(src) && isNixPathChar(src[i]) {
+			i++
+		}
+		return tok(nixPath, i)
+	case isNixIdentStart(c):
+		for i < len(src) && isNixIdentChar(src[i]) {
+			i++
+		}
+		return tok(nixIdent, i)
+	case c >= '0' && c <= '9':
+		for i < len(src) && isNixPathChar(src[i]) {
+			i++
+		}
+		return tok(nixOther, i)
+	}
+	return tok(nixPunct, i+1)
+}
+
+func lexNixString(src []byte, i int) (*nixToken, int, error) {
+	start := i
+	indented := src[i] != '"'
+	if indented {
+		i += 2
+	} else {
+		i++
+	}
+	interpolated := false
+	for i < len(src) {
+		rest := src[i:]
+		switch {
+		case !indented && src[i] == '\\':
+			i += 2
+			continue
+		case !indented && src[i] == '"',
+			indented && bytes.HasPrefix(rest, []byte("''")) &&
+				!bytes.HasPrefix(rest, []byte("'''")) &&
+				!bytes.HasPrefix(rest, []byte("''$")) &&
+				!bytes.HasPrefix(rest, []byte("''\\")):
+			end := i + 1
+			if indented {
+				end = i + 2
+			}
+			return &nixToken{
+				nixString,
+				string(src[start:end]),
+				start,
+				end,
+				interpolated,
+			}, end, nil
+		case indented && (bytes.HasPrefix(rest, []byte("'''")) ||
+			bytes.HasPrefix(rest, []byte("''$")) ||
+			bytes.HasPrefix(rest, []byte("''\\"))):
+			i += 3
+			continue
+		case bytes.HasPrefix(rest, []byte("${")):
+			interpolated = true
+			next, err := skipNixInterpolation(src, i+2)
+			if err != nil {
+				return nil, 0, err
+			}
+			i = next
+			continue
+		}
+		i++
+	}
+	return nil, 0, errors.New("unterminated string")
+}
+
+// Returns index after `}` closing interpolation started before i.
+func skipNixInterpolation(src []byte, i int) (int, error) {
+	depth := 1
+	for i < len(src) {
+		tok, next, err := nextNixToken(src, i)
+		if err != nil {
+			return 0, err
+		}
+		i = next
+		if tok == nil || tok.kind != nixPunct {
+			continue
+		}
+		switch tok.text {
+		case "{":
+			depth++
+		case "}":
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, errors.New("unterminated interpolation")
+}
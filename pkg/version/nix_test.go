@@ -0,0 +1,158 @@
+package version
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/asciimoth/rewrite"
+)
+
+// Returns value of string attribute found like NixSource does.
+func lookupNix(src string, kp []string) (string, error) {
+	toks, err := lexNix([]byte(src))
+	if err != nil {
+		return "", err
+	}
+	p := &nixParser{toks: toks}
+	p.parseExpr(0, nil)
+	b, err := p.resolve(kp, 0)
+	if b == nil || err != nil {
+		return "", err
+	}
+	val, err := nixValueOf(nil, "flake.nix", []byte(src), b)
+	if err != nil {
+		return "", err
+	}
+	return val.value, nil
+}
+
+func TestLexNix(t *testing.T) {
+	src := `{ a = "x\"y"; /* c */ b = ''z''${"q"}''; # d
+	c = ./v.txt; n = 1.5; }`
+	toks, err := lexNix([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	texts := []string{}
+	for _, tok := range toks {
+		texts = append(texts, tok.text)
+	}
+	want := []string{
+		"{", "a", "=", `"x\"y"`, ";", "b", "=", `''z''${"q"}''`, ";",
+		"c", "=", "./v.txt", ";", "n", "=", "1.5", ";", "}",
+	}
+	if !slices.Equal(texts, want) {
+		t.Errorf("tokens = %q, want %q", texts, want)
+	}
+	for _, bad := range []string{`"abc`, "''abc", "/* abc", `"${ "a" `} {
+		if _, err := lexNix([]byte(bad)); err == nil {
+			t.Errorf("lexNix(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestNixLookup(t *testing.T) {
+	tests := []struct {
+		src  string
+		kp   []string
+		want string
+		err  bool
+	}{
+		{`{ version = "1.2.3"; }`, []string{"version"}, "1.2.3", false},
+		{`{ a.b.version = "1.2.3"; }`, []string{"b", "version"}, "1.2.3", false},
+		{
+			`{ outputs = { ... }: let app = mk { version = "1.2.3"; }; in {
+			  packages.default = app; }; }`,
+			[]string{"packages", "default", "version"}, "1.2.3", false,
+		},
+		{`{ version = ''1.2.3''; }`, []string{"version"}, "1.2.3", false},
+		{`{ x.version = "1"; y.version = "2"; }`, []string{"version"}, "", true},
+		{`{ version = "${v}.0"; }`, []string{"version"}, "", true},
+		{`{ name = "x"; }`, []string{"version"}, "", false},
+	}
+	for _, tt := range tests {
+		got, err := lookupNix(tt.src, tt.kp)
+		if (err != nil) != tt.err {
+			t.Errorf("%q: error = %v, want error %t", tt.src, err, tt.err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: value = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+// Lexing must be linear, big files took minutes when every token copied
+// the rest of file.
+func TestLexNixLargeInput(t *testing.T) {
+	var b bytes.Buffer
+	b.WriteString(`{ blob = "`)
+	b.WriteString(strings.Repeat("a", 2<<20))
+	b.WriteString(`"; `)
+	for range 20000 {
+		b.WriteString(`x = ./a/b; /* c */ y = ''z''; `)
+	}
+	b.WriteString(`version = "1.2.3"; }`)
+	got, err := lookupNix(b.String(), []string{"version"})
+	if err != nil || got != "1.2.3" {
+		t.Errorf("value = %q, %v, want 1.2.3", got, err)
+	}
+}
+
+func TestNixSet(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		file  string
+		after string
+	}{
+		{
+			name: "literal",
+			files: map[string]string{
+				"flake.nix": "{\n  # app\n  version = \"1.2.0\"; # current\n}\n",
+			},
+			file:  "flake.nix",
+			after: "{\n  # app\n  version = \"1.3.0\"; # current\n}\n",
+		},
+		{
+			name: "readFile",
+			files: map[string]string{
+				"flake.nix": "{ version = builtins.readFile ./VERSION; }\n",
+				"VERSION":   "1.2.0\n",
+			},
+			file:  "VERSION",
+			after: "1.3.0\n",
+		},
+	}
+	for _, tt := range tests {
+		fs := testFS(t, tt.files)
+		src := &NixSource{Path: "flake.nix", KeyPath: []string{"version"}}
+		err := src.Set(*semver.MustParse("1.2.0"), fs)
+		if !errors.Is(err, ErrNoChanges) {
+			t.Errorf("%s: Set of current version = %v, want ErrNoChanges", tt.name, err)
+		}
+		if err := src.Set(*semver.MustParse("1.3.0"), fs); err != nil {
+			t.Errorf("%s: Set: %s", tt.name, err)
+			continue
+		}
+		data, err := rewrite.Read(fs, tt.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tt.after {
+			t.Errorf("%s: %s after Set = %q, want %q", tt.name, tt.file, data, tt.after)
+		}
+		v, err := src.Get(fs)
+		if err != nil || v.String() != "1.3.0" {
+			t.Errorf("%s: Get after Set = %v, %v, want 1.3.0", tt.name, v, err)
+		}
+		err = src.Set(*semver.MustParse("1.3.0"), fs)
+		if !errors.Is(err, ErrNoChanges) {
+			t.Errorf("%s: repeated Set = %v, want ErrNoChanges", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package version
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+)
+
+// Prefix of env vars overriding config keys.
+const envOverridePrefix = "VERSION_"
+
+// Types of top-level config keys that may be overridden.
+var overridableKeys = map[string]reflect.Type{
+	defaultVersionKey: reflect.TypeFor[string](),
+	schemeKey:         reflect.TypeFor[string](),
+	strictKey:         reflect.TypeFor[bool](),
+	ignoredFilesKey:   reflect.TypeFor[[]string](),
+	readOnlyFilesKey:  reflect.TypeFor[[]string](),
+}
+
+// Override sets config key to value given as text, e.g. from
+// `--set Sources.Git.ReadOnly=true` or VERSION_SOURCES_GIT_READONLY=true.
+// Overrides are applied on top of resolved config (or default sources)
+// and may add new sources.
+type Override struct {
+	// Dot separated path of key, matched case-insensitively
+	Key string
+	// Value is converted to type of key: "1" and "true" are both true,
+	// arrays are either comma separated or written as TOML arrays
+	Value string
+	// Where override comes from, e.g. env var name
+	Origin string
+}
+
+// ParseOverride parses override written as "Key.Path=value".
+func ParseOverride(s, origin string) (Override, error) {
+	key, val, ok := strings.Cut(s, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return Override{}, fmt.Errorf("override %q must look like Key.Path=value", s)
+	}
+	return Override{key, val, origin}, nil
+}
+
+// EnvOverrides returns overrides from env vars in os.Environ form, like
+// VERSION_STRICT=1 or VERSION_SOURCES_GIT_DISABLED=true: name without
+// prefix is key path with underscores as separators.
+// Vars not starting with overridable top-level key, e.g. VERSION_CONFIG,
+// are skipped.
+func EnvOverrides(environ []string) []Override {
+	overrides := []Override{}
+	for _, env := range environ {
+		name, val, _ := strings.Cut(env, "=")
+		path, ok := strings.CutPrefix(name, envOverridePrefix)
+		if !ok {
+			continue
+		}
+		keys := strings.Split(path, "_")
+		_, known := overridableKey(keys[0])
+		if !known && !strings.EqualFold(keys[0], sourcesKey) {
+			continue
+		}
+		overrides = append(overrides, Override{
+			strings.Join(keys, "."), val, name,
+		})
+	}
+	// Type goes first so options of new sources and schemes may follow it
+	isType := func(o Override) bool {
+		return strings.HasSuffix(o.Origin, "_"+strings.ToUpper(typeKey))
+	}
+	slices.SortFunc(overrides, func(a, b Override) int {
+		if isType(a) != isType(b) {
+			if isType(a) {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.Origin, b.Origin)
+	})
+	return overrides
+}
+
+// Returns overridable top-level key matching name case-insensitively.
+func overridableKey(name string) (string, bool) {
+	for key := range overridableKeys {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// Applies overrides to config in order, every applied override is traced.
+// Problems are reported as ConfigError with override origin as file.
+func applyOverrides(m map[string]any, overrides []Override, trace Log) error {
+	for _, o := range overrides {
+		key, err := applyOverride(m, o)
+		if err != nil {
+			return ConfigError{{File: o.Origin, Key: o.Key, Msg: err.Error()}}
+		}
+		trace(fmt.Sprintf("override %s = %s (%s)", key, o.Value, o.Origin))
+	}
+	return nil
+}
+
+// Applies override, returns path of overridden key as it is spelled in
+// config.
+func applyOverride(m map[string]any, o Override) (string, error) {
+	keys := strings.Split(o.Key, ".")
+	if key, ok := overridableKey(keys[0]); ok {
+		if len(keys) == 1 {
+			return key, setOverride(m, key, overridableKeys[key], o.Value)
+		}
+		if key != schemeKey || len(keys) != 2 {
+			return "", errors.New("unknown key")
+		}
+		opt, err := overrideSchemeOption(m, keys[1], o.Value)
+		return key + "." + opt, err
+	}
+	if !strings.EqualFold(keys[0], sourcesKey) {
+		return "", errors.New("unknown key")
+	}
+	if len(keys) != 3 {
+		return "", errors.New("expected Sources.Name.Key")
+	}
+	srcs, ok := m[tableKey(m, sourcesKey)].(map[string]any)
+	if !ok {
+		srcs = map[string]any{}
+		m[tableKey(m, sourcesKey)] = srcs
+	}
+	name := tableKey(srcs, keys[1])
+	src, ok := srcs[name].(map[string]any)
+	if !ok {
+		src = map[string]any{}
+		srcs[name] = src
+	}
+	path := sourcesKey + "." + name + "."
+	for key, typ := range sourceMetaKeys {
+		if strings.EqualFold(key, keys[2]) {
+			return path + key, setOverride(src, key, typ, o.Value)
+		}
+	}
+	srcType, _ := src[tableKey(src, typeKey)].(string)
+	constructor, ok := sources[srcType]
+	if srcType == "" {
+		return "", errors.New("source has no Type, set it first")
+	}
+	if !ok {
+		return "", fmt.Errorf("unknown source type %q", srcType)
+	}
+	for _, f := range optionFields(reflect.TypeOf(constructor())) {
+		if strings.EqualFold(f.name, keys[2]) {
+			return path + f.name, setOverride(src, f.name, f.typ, o.Value)
+		}
+	}
+	return "", errors.New("unknown key")
+}
+
+// Overrides Scheme option, returns its name as it is spelled in config.
+func overrideSchemeOption(m map[string]any, name, raw string) (string, error) {
+	scheme, ok := m[tableKey(m, schemeKey)].(map[string]any)
+	if !ok {
+		typ, _ := m[tableKey(m, schemeKey)].(string)
+		scheme = map[string]any{typeKey: typ}
+		m[tableKey(m, schemeKey)] = scheme
+	}
+	if strings.EqualFold(name, typeKey) {
+		return typeKey, setOverride(scheme, typeKey, reflect.TypeFor[string](), raw)
+	}
+	typ, _ := scheme[tableKey(scheme, typeKey)].(string)
+	constructor, ok := schemes[strings.ToLower(cmp.Or(typ, "semver"))]
+	if !ok {
+		return "", fmt.Errorf("unknown scheme type %q", typ)
+	}
+	for _, f := range optionFields(reflect.TypeOf(constructor())) {
+		if strings.EqualFold(f.name, name) {
+			return f.name, setOverride(scheme, f.name, f.typ, raw)
+		}
+	}
+	return "", errors.New("unknown key")
+}
+
+// Sets table key (existing one matched case-insensitively) to raw value
+// converted to typ.
+func setOverride(table map[string]any, key string, typ reflect.Type, raw string) error {
+	val, err := overrideValue(raw, typ)
+	if err != nil {
+		return err
+	}
+	table[tableKey(table, key)] = val
+	return nil
+}
+
+// Returns existing key of table matching key case-insensitively or key.
+func tableKey(table map[string]any, key string) string {
+	if k, ok := findKey(table, key); ok {
+		return k
+	}
+	return key
+}
+
+// Converts override text to config value of type.
+func overrideValue(raw string, typ reflect.Type) (any, error) {
+	raw = strings.TrimSpace(raw)
+	switch typ.Kind() {
+	case reflect.String:
+		return raw, nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("expected bool, got %q", raw)
+		}
+		return b, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected integer, got %q", raw)
+		}
+		return n, nil
+	case reflect.Slice:
+		if strings.HasPrefix(raw, "[") {
+			break
+		}
+		items := []any{}
+		for item := range strings.SplitSeq(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items, nil
+	}
+	tree, err := toml.Load("v = " + raw)
+	if err != nil {
+		return nil, errors.New("value must be written in TOML syntax")
+	}
+	return tree.ToMap()["v"], nil
+}
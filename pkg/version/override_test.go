@@ -0,0 +1,90 @@
+package version
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEnvOverrides(t *testing.T) {
+	overrides := EnvOverrides([]string{
+		"HOME=/root",
+		"VERSION_CONFIG=ci.toml",
+		"VERSION_SOURCES_NEW_PATH=VERSION",
+		"VERSION_STRICT=1",
+		"VERSION_SOURCES_NEW_TYPE=regexp",
+	})
+	want := []Override{
+		{"SOURCES.NEW.TYPE", "regexp", "VERSION_SOURCES_NEW_TYPE"},
+		{"SOURCES.NEW.PATH", "VERSION", "VERSION_SOURCES_NEW_PATH"},
+		{"STRICT", "1", "VERSION_STRICT"},
+	}
+	if !slices.Equal(overrides, want) {
+		t.Errorf("EnvOverrides = %v, want %v", overrides, want)
+	}
+	if _, err := ParseOverride("Strict", "--set"); err == nil {
+		t.Error("override without value parsed")
+	}
+}
+
+// Env overrides are applied before --set ones, so the latter win.
+func TestOverridesPrecedence(t *testing.T) {
+	fs := testFS(t, map[string]string{
+		"version.toml": "DefaultVersion = \"1.0.0\"\n" +
+			"[Sources.Git]\nType = \"git\"\n",
+	})
+	set, err := ParseOverride("defaultversion=3.0.0", "--set")
+	if err != nil {
+		t.Fatal(err)
+	}
+	overrides := append(EnvOverrides([]string{
+		"VERSION_DEFAULTVERSION=2.0.0",
+		"VERSION_SOURCES_GIT_DISABLED=true",
+		"VERSION_IGNOREDFILES=a, b",
+	}), set)
+	trace := []string{}
+	nop := func(string) {}
+	g, err := GroupFromConfig(fs, func(s string) {
+		trace = append(trace, s)
+	}, nop, nop, false, overrides...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.DefaultVersion != "3.0.0" || !g.Sources["Git"].Disabled ||
+		!slices.Equal(g.IgnoredFiles, []string{"a", "b"}) {
+		t.Errorf("DefaultVersion = %q, Git disabled %t, IgnoredFiles %q",
+			g.DefaultVersion, g.Sources["Git"].Disabled, g.IgnoredFiles)
+	}
+	want := []string{
+		"override DefaultVersion = 2.0.0 (VERSION_DEFAULTVERSION)",
+		"override IgnoredFiles = a, b (VERSION_IGNOREDFILES)",
+		"override Sources.Git.Disabled = true (VERSION_SOURCES_GIT_DISABLED)",
+		"override DefaultVersion = 3.0.0 (--set)",
+	}
+	trace = slices.DeleteFunc(trace, func(s string) bool {
+		return !strings.HasPrefix(s, "override ")
+	})
+	if !slices.Equal(trace, want) {
+		t.Errorf("traced overrides:\n%s\nwant:\n%s", strings.Join(trace, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestOverrideErrors(t *testing.T) {
+	tests := []Override{
+		{"Bogus", "1", "--set"},
+		{"Strict", "maybe", "VERSION_STRICT"},
+		{"Sources.Git", "x", "--set"},
+		{"Sources.New.Path", "VERSION", "--set"},
+		{"Sources.Git.Bogus", "x", "--set"},
+	}
+	nop := func(string) {}
+	for _, o := range tests {
+		fs := testFS(t, map[string]string{"version.toml": "[Sources.Git]\nType = \"git\"\n"})
+		_, err := GroupFromConfig(fs, nop, nop, nop, false, o)
+		var cerr ConfigError
+		if !errors.As(err, &cerr) || len(cerr) != 1 || cerr[0].File != o.Origin || cerr[0].Key != o.Key {
+			t.Errorf("override %s=%s: error %v, want one from %s", o.Key, o.Value, err, o.Origin)
+		}
+	}
+}
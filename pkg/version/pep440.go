@@ -0,0 +1,213 @@
+package version
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// PEP 440 version, see
+// https://packaging.python.org/en/latest/specifications/version-specifiers/
+var pep440Regexp = regexp.MustCompile(`(?i)^\s*(v?)` +
+	`(?:(\d+)!)?` +
+	`(\d+(?:\.\d+)*)` +
+	`(?:[-_.]?(a|b|c|rc|alpha|beta|pre|preview)[-_.]?(\d*))?` +
+	`(?:-(\d+)|[-_.]?(post|rev|r)[-_.]?(\d*))?` +
+	`(?:[-_.]?(dev)[-_.]?(\d*))?` +
+	`(?:\+([a-z0-9]+(?:[-_.][a-z0-9]+)*))?\s*$`)
+
+// PEP 440 pre-release label -> SemVer prerelease identifier.
+var pep440PreLabels = map[string]string{
+	"a":       "alpha",
+	"alpha":   "alpha",
+	"b":       "beta",
+	"beta":    "beta",
+	"c":       "rc",
+	"rc":      "rc",
+	"pre":     "rc",
+	"preview": "rc",
+}
+
+// SemVer prerelease identifier -> PEP 440 pre-release label.
+var semverPreLabels = map[string]string{
+	"a":     "a",
+	"alpha": "a",
+	"b":     "b",
+	"beta":  "b",
+	"rc":    "rc",
+	"c":     "rc",
+	"pre":   "rc",
+}
+
+func init() {
+	RegisterDialect("pep440", func(s Scheme) Scheme {
+		return &pep440Dialect{s}
+	})
+}
+
+// Leading prerelease identifiers keeping PEP 440 ordering: numeric ones
+// sort before alpha, beta and rc, post releases go before dev ones of the
+// next patch release.
+const (
+	pep440PostRank = "0"
+	pep440DevRank  = "1"
+)
+
+// pep440Dialect translates PEP 440 versions used by Python tooling to
+// SemVer ones used by scheme keeping their order:
+//   - 1.2.0rc1 <-> 1.2.0-rc.1 (a, b -> alpha, beta)
+//   - 1.2.0.dev3 <-> 1.2.0-1.dev.3, before 1.2.0-alpha
+//   - 1.2.0.post1 <-> 1.2.1-0.post.1, after 1.2.0 and before 1.2.1.dev0
+//   - 1.2.0+local <-> 1.2.0+local
+//
+// Post and dev parts of pre and post releases are appended to them, e.g.
+// 1.2.0rc1.post2.dev3 <-> 1.2.0-rc.1.post.2.dev.3, so 1.2.0rc1.dev3 and
+// 1.2.0.post1.dev3 sort after their base version unlike in PEP 440.
+type pep440Dialect struct {
+	Scheme
+}
+
+func (d *pep440Dialect) Parse(s string) (*semver.Version, error) {
+	str, err := pep440ToSemver(s)
+	if err != nil {
+		return nil, err
+	}
+	return d.Scheme.Parse(str)
+}
+
+func (d *pep440Dialect) Format(v *semver.Version) string {
+	return semverToPep440(d.Scheme.Format(v))
+}
+
+func pep440ToSemver(s string) (string, error) {
+	m := pep440Regexp.FindStringSubmatch(s)
+	if m == nil {
+		return "", fmt.Errorf("%q is not a PEP 440 version", s)
+	}
+	if m[2] != "" && m[2] != "0" {
+		return "", errors.New("PEP 440 epochs are not supported")
+	}
+	res := m[1] + m[3]
+	pre := []string{}
+	post := m[6]
+	if m[7] != "" {
+		post = pep440Number(m[8])
+	}
+	switch {
+	case m[4] != "":
+		pre = append(
+			pre, pep440PreLabels[strings.ToLower(m[4])], pep440Number(m[5]),
+		)
+		if post != "" {
+			pre = append(pre, "post", pep440Number(post))
+		}
+	case post != "":
+		// Post release is a prerelease of the next patch one
+		v, err := semver.NewVersion(m[3])
+		if err != nil {
+			return "", err
+		}
+		res = fmt.Sprintf("%s%d.%d.%d", m[1], v.Major(), v.Minor(), v.Patch()+1)
+		pre = append(pre, pep440PostRank, "post", pep440Number(post))
+	case m[9] != "":
+		pre = append(pre, pep440DevRank)
+	}
+	if m[9] != "" {
+		pre = append(pre, "dev", pep440Number(m[10]))
+	}
+	if len(pre) > 0 {
+		res += "-" + strings.Join(pre, ".")
+	}
+	build := []string{}
+	if m[11] != "" {
+		build = append(build, strings.FieldsFunc(m[11], func(r rune) bool {
+			return r == '-' || r == '_' || r == '.'
+		})...)
+	}
+	if len(build) > 0 {
+		res += "+" + strings.Join(build, ".")
+	}
+	return res, nil
+}
+
+// Converts SemVer text to PEP 440 one.
+// Prerelease identifiers that can't be represented are kept as is.
+func semverToPep440(s string) string {
+	rest, build, _ := strings.Cut(s, "+")
+	release, pre, _ := strings.Cut(rest, "-")
+	ids := strings.Split(pre, ".")
+	if pre == "" {
+		ids = nil
+	}
+	var suffix strings.Builder
+	switch {
+	case len(ids) >= 2 && ids[0] == pep440PostRank && ids[1] == "post":
+		prefix, numbers := "", release
+		if after, ok := strings.CutPrefix(release, "v"); ok {
+			prefix, numbers = "v", after
+		}
+		v, err := semver.StrictNewVersion(numbers)
+		if err != nil || v.Patch() == 0 {
+			return s
+		}
+		release = fmt.Sprintf("%s%d.%d.%d", prefix, v.Major(), v.Minor(), v.Patch()-1)
+		n := "0"
+		ids = ids[2:]
+		if len(ids) > 0 && isNumeric(ids[0]) {
+			n = ids[0]
+			ids = ids[1:]
+		}
+		suffix.WriteString(".post" + n)
+	case len(ids) >= 2 && ids[0] == pep440DevRank && ids[1] == "dev":
+		ids = ids[1:]
+	}
+	var b strings.Builder
+	b.WriteString(release)
+	for i := 0; i < len(ids); i++ {
+		label := strings.ToLower(ids[i])
+		n := "0"
+		if i+1 < len(ids) && isNumeric(ids[i+1]) {
+			n = ids[i+1]
+			i++
+		}
+		switch {
+		case label == "dev", label == "post" && b.Len() > len(release):
+			suffix.WriteString("." + label + n)
+		case semverPreLabels[label] != "" && suffix.Len() == 0:
+			b.WriteString(semverPreLabels[label] + n)
+		default:
+			// Not representable in PEP 440
+			return s
+		}
+	}
+	b.WriteString(suffix.String())
+	if build != "" {
+		b.WriteString("+" + build)
+	}
+	return b.String()
+}
+
+// Returns normalised PEP 440 number, implicit one is zero.
+func pep440Number(s string) string {
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return "0"
+	}
+	return strconv.FormatUint(n, 10)
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
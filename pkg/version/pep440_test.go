@@ -0,0 +1,115 @@
+package version
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+func TestPep440RoundTrip(t *testing.T) {
+	tests := []struct {
+		pep440 string
+		semver string
+	}{
+		{"1.2.0", "1.2.0"},
+		{"v1.2.0", "v1.2.0"},
+		{"1.2.0a1", "1.2.0-alpha.1"},
+		{"1.2.0b2", "1.2.0-beta.2"},
+		{"1.2.0rc1", "1.2.0-rc.1"},
+		{"1.2.0.dev3", "1.2.0-1.dev.3"},
+		{"1.2.0rc1.dev3", "1.2.0-rc.1.dev.3"},
+		{"1.2.0.post1", "1.2.1-0.post.1"},
+		{"v1.2.0.post1", "v1.2.1-0.post.1"},
+		{"1.2.0.post1.dev2", "1.2.1-0.post.1.dev.2"},
+		{"1.2.0rc1.post2", "1.2.0-rc.1.post.2"},
+		{"1.2.0+local.1", "1.2.0+local.1"},
+		{"1.2.0.post1+local", "1.2.1-0.post.1+local"},
+	}
+	for _, tt := range tests {
+		got, err := pep440ToSemver(tt.pep440)
+		if err != nil {
+			t.Errorf("pep440ToSemver(%q): %s", tt.pep440, err)
+			continue
+		}
+		if got != tt.semver {
+			t.Errorf("pep440ToSemver(%q) = %q, want %q", tt.pep440, got, tt.semver)
+		}
+		if back := semverToPep440(tt.semver); back != tt.pep440 {
+			t.Errorf("semverToPep440(%q) = %q, want %q", tt.semver, back, tt.pep440)
+		}
+	}
+}
+
+func TestPep440Normalisation(t *testing.T) {
+	tests := []struct {
+		pep440 string
+		semver string
+	}{
+		{"1.2.0-RC1", "1.2.0-rc.1"},
+		{"1.2.0.alpha.1", "1.2.0-alpha.1"},
+		{"1.2.0c1", "1.2.0-rc.1"},
+		{"1.2.0-1", "1.2.1-0.post.1"},
+		{"1.2.0.rev2", "1.2.1-0.post.2"},
+		{"1.2.0.post", "1.2.1-0.post.0"},
+		{"1.2.0dev", "1.2.0-1.dev.0"},
+		{"0!1.2.0", "1.2.0"},
+	}
+	for _, tt := range tests {
+		got, err := pep440ToSemver(tt.pep440)
+		if err != nil || got != tt.semver {
+			t.Errorf("pep440ToSemver(%q) = %q, %v, want %q", tt.pep440, got, err, tt.semver)
+		}
+	}
+	for _, bad := range []string{"1!1.2.0", "1.2.0-foo", "latest"} {
+		if _, err := pep440ToSemver(bad); err == nil {
+			t.Errorf("pep440ToSemver(%q) succeeded, want error", bad)
+		}
+	}
+	// Not representable versions are kept
+	for _, s := range []string{"1.2.0-foo.1", "1.2.0-0.post.1"} {
+		if got := semverToPep440(s); got != s {
+			t.Errorf("semverToPep440(%q) = %q, want it unchanged", s, got)
+		}
+	}
+}
+
+// Translated versions must sort like PEP 440 ones.
+func TestPep440Order(t *testing.T) {
+	ordered := []string{
+		"1.2.0",
+		"1.2.0.post1",
+		"1.2.0.post2",
+		"1.2.1.dev1",
+		"1.2.1a1",
+		"1.2.1a1.post1",
+		"1.2.1b1",
+		"1.2.1rc1",
+		"1.2.1",
+		"1.2.1.post1",
+		"1.3.0.dev0",
+		"1.3.0",
+	}
+	versions := []*semver.Version{}
+	for _, s := range ordered {
+		str, err := pep440ToSemver(s)
+		if err != nil {
+			t.Fatalf("pep440ToSemver(%q): %s", s, err)
+		}
+		versions = append(versions, semver.MustParse(str))
+	}
+	for i := 1; i < len(versions); i++ {
+		if !versions[i-1].LessThan(versions[i]) {
+			t.Errorf("%s (%s) is not less than %s (%s)",
+				ordered[i-1], versions[i-1], ordered[i], versions[i])
+		}
+	}
+	shuffled := slices.Clone(versions)
+	slices.Reverse(shuffled)
+	slices.SortFunc(shuffled, func(a, b *semver.Version) int { return a.Compare(b) })
+	if !slices.EqualFunc(shuffled, versions, func(a, b *semver.Version) bool {
+		return a.Equal(b)
+	}) {
+		t.Error("sorted versions don't follow PEP 440 order")
+	}
+}
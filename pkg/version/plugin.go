@@ -0,0 +1,162 @@
+package version
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Version of plugin protocol sent in every request.
+const pluginProtocol = 1
+
+// Prefix of plugin executables names looked up in PATH.
+const pluginPrefix = "version-source-"
+
+func init() {
+	RegisterSource("plugin", func() Source { return &PluginSource{} })
+}
+
+// PluginSource delegates reading and writing version to external
+// executable, either `version-source-<Plugin>` from PATH or Cmd.
+// Executable is started for every request, gets single JSON request on
+// stdin and must print single JSON response to stdout:
+//
+//	{"protocol": 1, "command": "capabilities", "options": {...}}
+//	-> {"readOnly": false, "canBeLesser": false}
+//	{"protocol": 1, "command": "get", "options": {...}}
+//	-> {"version": "1.2.3"} or {} if there is no version
+//	{"protocol": 1, "command": "set", "version": "1.2.3", "options": {...}}
+//	-> {"changed": true}
+//
+// Any response may be {"error": "message"} instead.
+// Options are passed from config as is.
+type PluginSource struct {
+	Plugin   string
+	Cmd      []string
+	Options  map[string]any
+	ReadOnly bool
+	CD       string
+	Env      map[string]string
+
+	caps *pluginCapabilities
+}
+
+type pluginRequest struct {
+	Protocol int            `json:"protocol"`
+	Command  string         `json:"command"`
+	Version  string         `json:"version,omitempty"`
+	Options  map[string]any `json:"options"`
+}
+
+type pluginResponse struct {
+	pluginCapabilities
+
+	Version *string `json:"version"`
+	Changed bool    `json:"changed"`
+	Error   string  `json:"error"`
+}
+
+type pluginCapabilities struct {
+	ReadOnly    bool `json:"readOnly"`
+	CanBeLesser bool `json:"canBeLesser"`
+}
+
+// Returns command line of plugin.
+func (p *PluginSource) args() ([]string, error) {
+	if len(p.Cmd) > 0 {
+		return p.Cmd, nil
+	}
+	if p.Plugin == "" {
+		return nil, errors.New("plugin source requires Plugin or Cmd")
+	}
+	path, err := exec.LookPath(pluginPrefix + p.Plugin)
+	if err != nil {
+		return nil, err
+	}
+	return []string{path}, nil
+}
+
+func (p *PluginSource) call(req pluginRequest) (*pluginResponse, error) {
+	args, err := p.args()
+	if err != nil {
+		return nil, err
+	}
+	cmd, err := constructCmd(args, p.CD, p.Env)
+	if err != nil {
+		return nil, err
+	}
+	req.Protocol = pluginProtocol
+	req.Options = p.Options
+	in, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	var stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("plugin %s: %w: %s", args[0], err, msg)
+		}
+		return nil, fmt.Errorf("plugin %s: %w", args[0], err)
+	}
+	var resp pluginResponse
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return nil, fmt.Errorf("plugin %s: invalid response: %w", args[0], err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("plugin %s: %s", args[0], resp.Error)
+	}
+	return &resp, nil
+}
+
+// Returns plugin capabilities, they are requested once.
+// Plugin failures are reported later by Get and Set.
+func (p *PluginSource) capabilities() pluginCapabilities {
+	if p.caps == nil {
+		p.caps = &pluginCapabilities{}
+		if resp, err := p.call(pluginRequest{Command: "capabilities"}); err == nil {
+			p.caps = &resp.pluginCapabilities
+		}
+	}
+	return *p.caps
+}
+
+func (p *PluginSource) IsCanBeLesser() bool {
+	return p.capabilities().CanBeLesser
+}
+
+func (p *PluginSource) IsReadOnly() bool {
+	return p.ReadOnly || p.capabilities().ReadOnly
+}
+
+func (p *PluginSource) Get(fs FS) (*semver.Version, error) {
+	resp, err := p.call(pluginRequest{Command: "get"})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Version == nil || *resp.Version == "" {
+		return nil, nil //nolint:nilnil
+	}
+	return ParseVersion(fs, *resp.Version)
+}
+
+func (p *PluginSource) Set(v semver.Version, fs FS) error {
+	resp, err := p.call(pluginRequest{
+		Command: "set",
+		Version: FormatVersion(fs, &v),
+	})
+	if err != nil {
+		return err
+	}
+	if !resp.Changed {
+		return ErrNoChanges
+	}
+	return nil
+}
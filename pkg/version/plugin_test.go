@@ -0,0 +1,119 @@
+package version
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Test binary itself serves as plugin keeping version in file from
+// "file" option.
+func TestPluginHelper(t *testing.T) {
+	if os.Getenv("VERSION_TEST_PLUGIN") == "" {
+		t.Skip("not started as plugin")
+	}
+	var req pluginRequest
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	resp := map[string]any{}
+	file, _ := req.Options["file"].(string)
+	switch {
+	case req.Protocol != pluginProtocol:
+		resp["error"] = fmt.Sprintf("unsupported protocol %d", req.Protocol)
+	case req.Options["mode"] == "fail":
+		fmt.Fprintln(os.Stderr, "boom")
+		os.Exit(3)
+	case req.Options["mode"] == "garbage":
+		fmt.Print("not json")
+		os.Exit(0)
+	case req.Command == "capabilities":
+		resp["readOnly"] = req.Options["readOnly"] == true
+		resp["canBeLesser"] = true
+	case req.Command == "get":
+		data, err := os.ReadFile(file)
+		if err == nil {
+			resp["version"] = strings.TrimSpace(string(data))
+		}
+	case req.Command == "set":
+		data, _ := os.ReadFile(file)
+		if strings.TrimSpace(string(data)) != req.Version {
+			if err := os.WriteFile(file, []byte(req.Version+"\n"), 0o644); err != nil {
+				resp["error"] = err.Error()
+			}
+			resp["changed"] = true
+		}
+	default:
+		resp["error"] = "unknown command " + req.Command
+	}
+	if err := json.NewEncoder(os.Stdout).Encode(resp); err != nil {
+		os.Exit(2)
+	}
+	os.Exit(0)
+}
+
+// Returns source started as TestPluginHelper.
+func testPlugin(options map[string]any) *PluginSource {
+	return &PluginSource{
+		Cmd:     []string{os.Args[0], "-test.run=^TestPluginHelper$"},
+		Options: options,
+		Env:     map[string]string{"VERSION_TEST_PLUGIN": "1"},
+	}
+}
+
+func TestPluginSource(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "VERSION")
+	src := testPlugin(map[string]any{"file": file})
+	if src.IsReadOnly() || !src.IsCanBeLesser() {
+		t.Errorf("capabilities: readOnly %t, canBeLesser %t", src.IsReadOnly(), src.IsCanBeLesser())
+	}
+	if v, err := src.Get(nil); v != nil || err != nil {
+		t.Errorf("Get without version = %v, %v, want nil", v, err)
+	}
+	if err := src.Set(*semver.MustParse("1.2.0"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.Set(*semver.MustParse("1.2.0"), nil); !errors.Is(err, ErrNoChanges) {
+		t.Errorf("Set of current version: %v, want ErrNoChanges", err)
+	}
+	if v, err := src.Get(nil); err != nil || v == nil || v.String() != "1.2.0" {
+		t.Errorf("Get after Set = %v, %v, want 1.2.0", v, err)
+	}
+	if ro := testPlugin(map[string]any{"readOnly": true}); !ro.IsReadOnly() {
+		t.Error("plugin reporting readOnly is writable")
+	}
+	if ro := (&PluginSource{Cmd: src.Cmd, ReadOnly: true}); !ro.IsReadOnly() {
+		t.Error("plugin with ReadOnly option is writable")
+	}
+}
+
+func TestPluginSourceErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  *PluginSource
+		err  string
+	}{
+		{"no command", &PluginSource{}, "plugin source requires Plugin or Cmd"},
+		{"not in PATH", &PluginSource{Plugin: "missing-for-test"}, "version-source-missing-for-test"},
+		{"exit code", testPlugin(map[string]any{"mode": "fail"}), "exit status 3: boom"},
+		{"garbage", testPlugin(map[string]any{"mode": "garbage"}), "invalid response"},
+		{"error response", testPlugin(map[string]any{"file": t.TempDir()}), "is a directory"},
+	}
+	for _, tt := range tests {
+		err := tt.src.Set(*semver.MustParse("1.0.0"), nil)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: Set error %v, want %q", tt.name, err, tt.err)
+		}
+	}
+	// Failing plugin has no capabilities
+	if src := tests[2].src; src.IsReadOnly() || src.IsCanBeLesser() {
+		t.Error("failing plugin reports capabilities")
+	}
+}
@@ -0,0 +1,121 @@
+package version
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Policy kinds.
+const (
+	policyEqual      = "equal"
+	policyLag        = "lag"
+	policyMajorMinor = "major.minor"
+	policyRelease    = "release"
+	policyAtLeast    = ">="
+)
+
+// policy is per-source `Policy` rule describing how version reported by
+// source relates to the group one:
+//   - "equal" — must be equal (default)
+//   - "lag" — may lag by one release, e.g. git tag before it is created
+//   - "major.minor" — must match major and minor parts only
+//   - "release" — must be equal ignoring prerelease and build metadata
+//   - ">= Name" — must not be lower than version of source Name
+type policy struct {
+	kind string
+	// Source referenced by ">=" policy
+	source Name
+}
+
+func parsePolicy(text string) (policy, error) {
+	text = strings.TrimSpace(text)
+	if name, ok := strings.CutPrefix(text, policyAtLeast); ok {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return policy{}, fmt.Errorf("policy %q misses source name", text)
+		}
+		return policy{policyAtLeast, name}, nil
+	}
+	kind := strings.ToLower(text)
+	switch kind {
+	case policyEqual, policyLag, policyMajorMinor, policyRelease:
+		return policy{kind: kind}, nil
+	}
+	return policy{}, fmt.Errorf("unknown policy %q", text)
+}
+
+func (p policy) String() string {
+	if p.kind == policyAtLeast {
+		return policyAtLeast + " " + p.source
+	}
+	return p.kind
+}
+
+// Checks version v reported by source keeping parts against group version
+// ref. Returns description of violation or nil.
+func (p policy) check(
+	v, ref *semver.Version,
+	parts []string,
+	reports []Report,
+	format func(*semver.Version) string,
+) error {
+	switch p.kind {
+	case policyLag:
+		if partiallyEqual(ref, v, parts) {
+			return nil
+		}
+		if v.GreaterThan(ref) {
+			return fmt.Errorf("is ahead of %s", format(ref))
+		}
+		if !isNextRelease(releaseOf(v), releaseOf(ref)) {
+			return fmt.Errorf(
+				"lags behind %s by more than one release", format(ref),
+			)
+		}
+	case policyMajorMinor:
+		if v.Major() != ref.Major() || v.Minor() != ref.Minor() {
+			return fmt.Errorf("must match %d.%d", ref.Major(), ref.Minor())
+		}
+	case policyRelease:
+		if !partiallyEqual(releaseOf(ref), releaseOf(v), parts) {
+			return fmt.Errorf("must match release %s", format(releaseOf(ref)))
+		}
+	case policyAtLeast:
+		for _, r := range reports {
+			if r.Name != p.source || r.Version == nil {
+				continue
+			}
+			if v.LessThan(r.Version) {
+				return fmt.Errorf("must be >= %s (%s)", r.Name, format(r.Version))
+			}
+		}
+	default:
+		if !partiallyEqual(ref, v, parts) {
+			return fmt.Errorf("must equal %s", format(ref))
+		}
+	}
+	return nil
+}
+
+// Returns version without prerelease and build metadata.
+func releaseOf(v *semver.Version) *semver.Version {
+	return semver.New(v.Major(), v.Minor(), v.Patch(), "", "")
+}
+
+// Reports whether release next follows prev or it is the same release,
+// i.e. prev is its prerelease.
+func isNextRelease(prev, next *semver.Version) bool {
+	if next.Equal(prev) {
+		return true
+	}
+	for _, v := range []semver.Version{
+		prev.IncPatch(), prev.IncMinor(), prev.IncMajor(),
+	} {
+		if next.Equal(&v) {
+			return true
+		}
+	}
+	return false
+}
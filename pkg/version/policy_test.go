@@ -0,0 +1,131 @@
+package version
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+		err  bool
+	}{
+		{"", "", true},
+		{"Equal", "equal", false},
+		{" lag ", "lag", false},
+		{"major.minor", "major.minor", false},
+		{"release", "release", false},
+		{">=Git", ">= Git", false},
+		{">=  ", "", true},
+		{"newer", "", true},
+	}
+	for _, tt := range tests {
+		p, err := parsePolicy(tt.text)
+		if (err != nil) != tt.err || err == nil && p.String() != tt.want {
+			t.Errorf("parsePolicy(%q) = %q, %v, want %q", tt.text, p, err, tt.want)
+		}
+	}
+}
+
+func TestPolicyCheck(t *testing.T) {
+	reports := []Report{{Name: "Git", Version: semver.MustParse("1.2.0")}}
+	tests := []struct {
+		policy string
+		v, ref string
+		err    string
+	}{
+		{"equal", "1.2.3", "1.2.3", ""},
+		{"equal", "1.2.2", "1.2.3", "must equal 1.2.3"},
+		{"lag", "1.2.2", "1.2.3", ""},
+		{"lag", "1.2.0", "2.0.0", ""},
+		{"lag", "1.3.0-rc.1", "1.3.0", ""},
+		{"lag", "1.2.1", "1.2.3", "lags behind 1.2.3 by more than one release"},
+		{"lag", "1.2.4", "1.2.3", "is ahead of 1.2.3"},
+		{"major.minor", "1.2.0", "1.2.9-rc.1", ""},
+		{"major.minor", "1.3.0", "1.2.9", "must match 1.2"},
+		{"release", "1.2.3+build", "1.2.3-rc.1", ""},
+		{"release", "1.2.4", "1.2.3", "must match release 1.2.3"},
+		{">= Git", "1.2.0", "1.5.0", ""},
+		{">= Git", "1.1.9", "1.5.0", "must be >= Git (1.2.0)"},
+	}
+	parts := SourceWithMeta{}.parts()
+	for _, tt := range tests {
+		p, err := parsePolicy(tt.policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = p.check(
+			semver.MustParse(tt.v), semver.MustParse(tt.ref),
+			parts, reports, (*semver.Version).String,
+		)
+		if tt.err == "" && err != nil || tt.err != "" && (err == nil || err.Error() != tt.err) {
+			t.Errorf("%q policy for %s against %s: %v, want %q", tt.policy, tt.v, tt.ref, err, tt.err)
+		}
+	}
+}
+
+func TestGetWithPolicies(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources map[Name]SourceWithMeta
+		strict  bool
+		err     string
+	}{
+		{
+			name: "allowed",
+			sources: map[Name]SourceWithMeta{
+				"Npm":  {Source: &memSource{version: semver.MustParse("1.3.0")}},
+				"Git":  {Policy: "lag", Source: &memSource{version: semver.MustParse("1.2.5")}},
+				"Helm": {Policy: "major.minor", Source: &memSource{version: semver.MustParse("1.3.7")}},
+			},
+		},
+		{
+			name: "rejected",
+			sources: map[Name]SourceWithMeta{
+				"Npm":  {Source: &memSource{version: semver.MustParse("1.3.0")}},
+				"Helm": {Policy: "major.minor", Source: &memSource{version: semver.MustParse("1.4.0")}},
+			},
+			err: `Helm report version 1.4.0 violating "major.minor" policy: must match 1.3`,
+		},
+		{
+			// Sources without policy must be equal in strict mode
+			name: "strict",
+			sources: map[Name]SourceWithMeta{
+				"Npm": {Priority: 1, Source: &memSource{version: semver.MustParse("1.3.0")}},
+				"Git": {Source: &lesserSource{memSource{version: semver.MustParse("1.2.0")}}},
+			},
+			strict: true,
+			err:    `Git report version 1.2.0 violating "equal" policy: must equal 1.3.0`,
+		},
+	}
+	for _, tt := range tests {
+		nop := func(string) {}
+		errs := []string{}
+		g, err := NewGroupSource("", tt.sources, tt.strict, nop, nop, func(s string) {
+			errs = append(errs, strings.TrimSpace(s))
+		}, nil, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, err := g.Get(nil)
+		if tt.err == "" {
+			if err != nil || !v.Equal(semver.MustParse("1.3.0")) {
+				t.Errorf("%s: Get = %v, %v, want 1.3.0", tt.name, v, err)
+			}
+			continue
+		}
+		if err == nil || len(errs) != 1 || errs[0] != tt.err {
+			t.Errorf("%s: Get error %v, messages %q, want %q", tt.name, err, errs, tt.err)
+		}
+	}
+}
+
+// Source allowed to lag without explicit policy.
+type lesserSource struct {
+	memSource
+}
+
+func (s *lesserSource) IsCanBeLesser() bool { return true }
@@ -0,0 +1,93 @@
+package version
+
+import (
+	"github.com/Masterminds/semver/v3"
+	"github.com/asciimoth/inplace/regexp"
+)
+
+// Regexps locating quoted value of `<key> = "1.2.3"` like assignments.
+func assignmentKeyPath(key string) []string {
+	return []string{
+		key + `["']` + quotedValueRegexp,
+		`["']` + quotedValueRegexp,
+		`[^"']+`,
+	}
+}
+
+const quotedValueRegexp = `[^"'\s]+["']`
+
+func init() {
+	RegisterSource("regexp", func() Source { return &RegexpSource{} })
+	RegisterDefaultSource("Gemspec", SourceWithMeta{
+		VPrefix: VPrefixFalse,
+		Source: &RegexpSource{
+			"*.gemspec",
+			assignmentKeyPath(`\.version\s*=\s*`),
+		},
+	}, "*.gemspec")
+	RegisterDefaultSource("GemVersion", SourceWithMeta{
+		VPrefix: VPrefixFalse,
+		Source: &RegexpSource{
+			"lib/*/version.rb",
+			assignmentKeyPath(`\bVERSION\s*=\s*`),
+		},
+	}, "lib/*/version.rb")
+	RegisterDefaultSource("Podspec", SourceWithMeta{
+		VPrefix: VPrefixFalse,
+		Source: &RegexpSource{
+			"*.podspec",
+			assignmentKeyPath(`\.version\s*=\s*`),
+		},
+	}, "*.podspec")
+	RegisterDefaultSource("Mix", SourceWithMeta{
+		VPrefix: VPrefixFalse,
+		Source: &RegexpSource{
+			"mix.exs",
+			assignmentKeyPath(`\bversion:\s*`),
+		},
+	}, "mix.exs")
+	RegisterDefaultSource("Gradle", SourceWithMeta{
+		VPrefix: VPrefixFalse,
+		Source: &RegexpSource{
+			"build.gradle*",
+			assignmentKeyPath(`(?m)^\s*version\s*=?\s*`),
+		},
+	}, "build.gradle", "build.gradle.kts")
+	RegisterDefaultSource("SetupPy", SourceWithMeta{
+		VPrefix: VPrefixFalse,
+		Dialect: "pep440",
+		Source: &RegexpSource{
+			"setup.py",
+			assignmentKeyPath(`\bversion\s*=\s*`),
+		},
+	}, "setup.py")
+}
+
+type RegexpSource struct {
+	Path    string
+	KeyPath []string
+}
+
+func (d *RegexpSource) IsCanBeLesser() bool {
+	return false
+}
+
+func (d *RegexpSource) IsReadOnly() bool {
+	return false
+}
+
+func (d *RegexpSource) RequiredOptions() []string {
+	return []string{"Path", "KeyPath"}
+}
+
+func (d *RegexpSource) Files(fs FS) ([]string, error) {
+	return globFiles(fs, d.Path)
+}
+
+func (d *RegexpSource) Get(fs FS) (*semver.Version, error) {
+	return getFromDoc(fs, regexp.New, d.KeyPath, d.Path)
+}
+
+func (d *RegexpSource) Set(v semver.Version, fs FS) error {
+	return setToDoc(v, fs, regexp.New, d.KeyPath, d.Path)
+}
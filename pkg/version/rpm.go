@@ -0,0 +1,170 @@
+package version
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/asciimoth/rewrite"
+)
+
+var (
+	rpmVersionRegexp   = regexp.MustCompile(`(?im)^(Version:\s*)(\S+)(.*)$`)
+	rpmReleaseRegexp   = regexp.MustCompile(`(?im)^(Release:\s*)(\d+)(.*)$`)
+	rpmPackagerRegexp  = regexp.MustCompile(`(?im)^Packager:\s*(.+?)\s*$`)
+	rpmChangelogRegexp = regexp.MustCompile(`(?m)^%changelog[ \t]*\r?\n`)
+	rpmEntryRegexp     = regexp.MustCompile(
+		`(?m)^\* \S+ \S+ \d+ \d+ (.+?)(?: - \S+)?\s*$`,
+	)
+)
+
+func init() {
+	RegisterSource("rpmspec", func() Source { return &RPMSpecSource{} })
+}
+
+// RPMSpecSource reads `Version:` tag of RPM spec file and on Set updates it,
+// resets `Release:` number and adds a new `%changelog` entry.
+// Empty Packager is taken from RPM_PACKAGER env var, `Packager:` tag or
+// the top changelog entry. Empty Date means current time.
+type RPMSpecSource struct {
+	Path     string
+	Packager string
+	Release  string
+	Message  string
+	Date     string
+}
+
+func (d *RPMSpecSource) IsCanBeLesser() bool {
+	return false
+}
+
+func (d *RPMSpecSource) IsReadOnly() bool {
+	return false
+}
+
+func (d *RPMSpecSource) RequiredOptions() []string {
+	return []string{"Path"}
+}
+
+func (d *RPMSpecSource) Files(fs FS) ([]string, error) {
+	return globFiles(fs, d.Path)
+}
+
+func (d *RPMSpecSource) Get(fs FS) (*semver.Version, error) {
+	files, err := fs.Glob(d.Path)
+	if err != nil {
+		return nil, err
+	}
+	var v *semver.Version
+	for _, file := range files {
+		data, err := rewrite.Read(fs, file)
+		if err != nil {
+			return nil, err
+		}
+		m := rpmVersionRegexp.FindSubmatch(data)
+		if m == nil {
+			continue
+		}
+		val := strings.Replace(string(m[2]), "~", "-", 1)
+		if strings.Contains(val, "%") {
+			return nil, fmt.Errorf(
+				"%s: macros in Version are not supported", file,
+			)
+		}
+		cv, err := ParseVersion(fs, val)
+		if err != nil {
+			return nil, err
+		}
+		if v != nil && !v.Equal(cv) {
+			return nil, errUnsync
+		}
+		v = cv
+	}
+	return v, nil
+}
+
+func (d *RPMSpecSource) Set(v semver.Version, fs FS) error {
+	files, err := fs.Glob(d.Path)
+	if err != nil {
+		return err
+	}
+	val := strings.Replace(
+		strings.TrimPrefix(FormatVersion(fs, &v), "v"), "-", "~", 1,
+	)
+	changes := false
+	for _, file := range files {
+		data, err := rewrite.Read(fs, file)
+		if err != nil {
+			return err
+		}
+		m := rpmVersionRegexp.FindSubmatch(data)
+		if m == nil || string(m[2]) == val {
+			continue
+		}
+		data, err = d.update(data, val)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		err = rewrite.Write(fs, file, data)
+		if err != nil {
+			return err
+		}
+		changes = true
+	}
+	if changes {
+		return nil
+	}
+	return ErrNoChanges
+}
+
+func (d *RPMSpecSource) update(data []byte, val string) ([]byte, error) {
+	release := cmp.Or(d.Release, "1")
+	data = replaceFirst(rpmVersionRegexp, data, "${1}"+val+"${3}")
+	data = replaceFirst(rpmReleaseRegexp, data, "${1}"+release+"${3}")
+	packager := cmp.Or(d.Packager, os.Getenv("RPM_PACKAGER"))
+	if m := rpmPackagerRegexp.FindSubmatch(data); m != nil {
+		packager = cmp.Or(packager, string(m[1]))
+	}
+	if m := rpmEntryRegexp.FindSubmatch(data); m != nil {
+		packager = cmp.Or(packager, string(m[1]))
+	}
+	if packager == "" {
+		return nil, errors.New("rpm packager is not configured")
+	}
+	date, err := changelogDate(d.Date, "Mon Jan 02 2006")
+	if err != nil {
+		return nil, err
+	}
+	entry := fmt.Sprintf(
+		"* %s %s - %s-%s\n- %s\n\n",
+		date,
+		packager,
+		val,
+		release,
+		cmp.Or(d.Message, "New upstream release."),
+	)
+	loc := rpmChangelogRegexp.FindIndex(data)
+	if loc == nil {
+		if !bytes.HasSuffix(data, []byte("\n")) {
+			data = append(data, '\n')
+		}
+		return append(data, []byte("\n%changelog\n"+entry)...), nil
+	}
+	return slices.Insert(data, loc[1], []byte(entry)...), nil
+}
+
+// Replaces only the first match of re.
+func replaceFirst(re *regexp.Regexp, data []byte, repl string) []byte {
+	loc := re.FindSubmatchIndex(data)
+	if loc == nil {
+		return data
+	}
+	out := re.Expand(slices.Clone(data[:loc[0]]), []byte(repl), data, loc)
+	return append(out, data[loc[1]:]...)
+}
@@ -0,0 +1,75 @@
+package version
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/asciimoth/rewrite"
+)
+
+func TestRPMSpecSet(t *testing.T) {
+	t.Setenv("RPM_PACKAGER", "")
+	tests := []struct {
+		name   string
+		spec   string
+		result string
+	}{
+		{
+			name: "changelog",
+			spec: "Name: mypkg\nVersion:  1.2.0 # keep\nRelease: 3%{?dist}\n\n%changelog\n" +
+				"* Mon Jan 02 2006 Jane Doe <jane@example.com> - 1.2.0-3\n- Old.\n",
+			result: "Name: mypkg\nVersion:  1.3.0~rc.1 # keep\nRelease: 1%{?dist}\n\n%changelog\n" +
+				"* Wed May 01 2024 Jane Doe <jane@example.com> - 1.3.0~rc.1-1\n- New upstream release.\n\n" +
+				"* Mon Jan 02 2006 Jane Doe <jane@example.com> - 1.2.0-3\n- Old.\n",
+		},
+		{
+			name: "no changelog",
+			spec: "Name: mypkg\nVersion: 1.2.0\nRelease: 2\nPackager: Jane Doe <jane@example.com>",
+			result: "Name: mypkg\nVersion: 1.3.0~rc.1\nRelease: 1\nPackager: Jane Doe <jane@example.com>\n" +
+				"\n%changelog\n* Wed May 01 2024 Jane Doe <jane@example.com> - 1.3.0~rc.1-1\n" +
+				"- New upstream release.\n\n",
+		},
+	}
+	for _, tt := range tests {
+		fs := testFS(t, map[string]string{"mypkg.spec": tt.spec})
+		src := &RPMSpecSource{Path: "*.spec", Date: "2024-05-01"}
+		v, err := src.Get(fs)
+		if err != nil || v == nil || v.String() != "1.2.0" {
+			t.Errorf("%s: Get = %v, %v, want 1.2.0", tt.name, v, err)
+			continue
+		}
+		if err := src.Set(*v, fs); !errors.Is(err, ErrNoChanges) {
+			t.Errorf("%s: Set of current version: %v, want ErrNoChanges", tt.name, err)
+		}
+		if err := src.Set(*semver.MustParse("1.3.0-rc.1"), fs); err != nil {
+			t.Errorf("%s: Set: %s", tt.name, err)
+			continue
+		}
+		data, err := rewrite.Read(fs, "mypkg.spec")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tt.result {
+			t.Errorf("%s: spec after Set:\n%s\nwant:\n%s", tt.name, data, tt.result)
+		}
+		if v, err := src.Get(fs); err != nil || v.String() != "1.3.0-rc.1" {
+			t.Errorf("%s: Get after Set = %v, %v, want 1.3.0-rc.1", tt.name, v, err)
+		}
+	}
+}
+
+func TestRPMSpecErrors(t *testing.T) {
+	t.Setenv("RPM_PACKAGER", "")
+	fs := testFS(t, map[string]string{
+		"macro.spec":  "Version: %{ver}\n",
+		"nobody.spec": "Version: 1.0.0\nRelease: 1\n",
+	})
+	if _, err := (&RPMSpecSource{Path: "macro.spec"}).Get(fs); err == nil {
+		t.Error("Get of Version with macro succeeded")
+	}
+	src := &RPMSpecSource{Path: "nobody.spec"}
+	if err := src.Set(*semver.MustParse("2.0.0"), fs); err == nil {
+		t.Error("Set without packager succeeded")
+	}
+}
@@ -0,0 +1,144 @@
+package version
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/pelletier/go-toml"
+)
+
+// Scheme converts versions between text stored in sources and
+// semver.Version used internally.
+// Internal versions must keep ordering of the scheme, so they can be
+// compared as plain SemVer ones.
+type Scheme interface {
+	Parse(s string) (*semver.Version, error)
+	Format(v *semver.Version) string
+	// Returns next version, part is one of "major", "minor", "patch"
+	// or empty for scheme default increment
+	Bump(v *semver.Version, part string) (*semver.Version, error)
+}
+
+// Type -> default constructor.
+var schemes = map[string]func() Scheme{}
+
+func RegisterScheme(schemeType string, constructor func() Scheme) {
+	schemes[strings.ToLower(schemeType)] = constructor
+}
+
+// Name -> wrapper translating scheme versions to source dialect.
+var dialects = map[string]func(Scheme) Scheme{}
+
+func RegisterDialect(name string, wrapper func(Scheme) Scheme) {
+	dialects[strings.ToLower(name)] = wrapper
+}
+
+func init() {
+	RegisterScheme("semver", func() Scheme { return &SemVerScheme{} })
+	RegisterDialect("semver", func(s Scheme) Scheme { return s })
+}
+
+// SchemeConfig is `Scheme` option of group config.
+// It may be either scheme type name or table with Type and scheme options:
+//
+//	Scheme = "semver"
+//
+//	[Scheme]
+//	Type = "calver"
+//	Format = "YYYY.0M.MICRO"
+type SchemeConfig struct {
+	Type   string
+	Scheme Scheme
+}
+
+func (sc *SchemeConfig) UnmarshalTOML(data any) error {
+	m := map[string]any{}
+	switch val := data.(type) {
+	case string:
+		m["Type"] = val
+	case map[string]any:
+		m = val
+	default:
+		return errors.New("scheme must be a string or a table")
+	}
+	for key, val := range m {
+		if !strings.EqualFold(key, "type") {
+			continue
+		}
+		t, ok := val.(string)
+		if !ok {
+			return errors.New("scheme Type must be a string")
+		}
+		sc.Type = strings.ToLower(t)
+		delete(m, key)
+	}
+	constructor, ok := schemes[sc.Type]
+	if !ok {
+		return fmt.Errorf("unknown scheme type %q", sc.Type)
+	}
+	instance := constructor()
+	b, err := toml.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if err := toml.Unmarshal(b, instance); err != nil {
+		return err
+	}
+	sc.Scheme = instance
+	return nil
+}
+
+// SemVerScheme is default scheme.
+type SemVerScheme struct{}
+
+func (s *SemVerScheme) Parse(str string) (*semver.Version, error) {
+	return semver.NewVersion(str)
+}
+
+func (s *SemVerScheme) Format(v *semver.Version) string {
+	return verToString(v)
+}
+
+func (s *SemVerScheme) Bump(
+	v *semver.Version,
+	part string,
+) (*semver.Version, error) {
+	var nv semver.Version
+	switch part {
+	case "major":
+		nv = v.IncMajor()
+	case "", "minor":
+		nv = v.IncMinor()
+	case "patch":
+		nv = v.IncPatch()
+	default:
+		return nil, fmt.Errorf("unknown version part %q", part)
+	}
+	return &nv, nil
+}
+
+// FS passed to sources, carries scheme used to parse and format versions.
+type schemeFS struct {
+	FS
+	scheme Scheme
+}
+
+// Returns scheme attached to fs, SemVer by default.
+func schemeOf(fs FS) Scheme { //nolint:ireturn
+	if sfs, ok := fs.(*schemeFS); ok {
+		return sfs.scheme
+	}
+	return &SemVerScheme{}
+}
+
+// Parses version text read by source with scheme attached to fs.
+func ParseVersion(fs FS, s string) (*semver.Version, error) {
+	return schemeOf(fs).Parse(s)
+}
+
+// Formats version to be written by source with scheme attached to fs.
+func FormatVersion(fs FS, v *semver.Version) string {
+	return schemeOf(fs).Format(v)
+}
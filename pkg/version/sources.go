@@ -0,0 +1,89 @@
+package version
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+type Source interface {
+	IsCanBeLesser() bool
+	IsReadOnly() bool
+	Get(fs FS) (*semver.Version, error)
+	Set(v semver.Version, fs FS) error
+}
+
+// FileSource is implemented by sources keeping version in files.
+// Files returns all files read or written by source.
+type FileSource interface {
+	Files(fs FS) ([]string, error)
+}
+
+// Type -> default constructor.
+var sources = map[string]func() Source{}
+
+// Name -> Source.
+var defaultSources = map[string]SourceWithMeta{}
+
+// Name -> file globs, default source is used only if any of them matches.
+var defaultSourcesDetect = map[string][]string{}
+
+func RegisterSource(srcType string, constructor func() Source) {
+	sources[srcType] = constructor
+}
+
+// Registers source used when there is no config.
+// If detect globs are provided, source is used only when any of them
+// matches some file in project.
+func RegisterDefaultSource(name string, src SourceWithMeta, detect ...string) {
+	defaultSources[name] = src
+	if len(detect) > 0 {
+		defaultSourcesDetect[name] = detect
+	}
+}
+
+// Returns copy of all registered default sources.
+func DefaultSources() map[Name]SourceWithMeta {
+	return maps.Clone(defaultSources)
+}
+
+// Returns file globs used to detect default source.
+func DefaultSourceDetect(name Name) []string {
+	return slices.Clone(defaultSourcesDetect[name])
+}
+
+// Returns default sources which files are present in fs.
+func DetectDefaultSources(fs FS) map[Name]SourceWithMeta {
+	srcs := make(map[Name]SourceWithMeta)
+	for name, src := range defaultSources {
+		detect, ok := defaultSourcesDetect[name]
+		if !ok {
+			srcs[name] = src
+			continue
+		}
+		for _, pattern := range detect {
+			if m, err := fs.Glob(pattern); err == nil && len(m) > 0 {
+				srcs[name] = src
+				break
+			}
+		}
+	}
+	return srcs
+}
+
+// Returns files matched by non-empty globs without duplicates.
+func globFiles(fs FS, globs ...string) ([]string, error) {
+	files := []string{}
+	for _, glob := range globs {
+		if glob == "" {
+			continue
+		}
+		m, err := fs.Glob(glob)
+		if err != nil {
+			return nil, err
+		}
+		files = appendUnique(files, m...)
+	}
+	return files, nil
+}
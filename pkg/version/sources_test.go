@@ -0,0 +1,49 @@
+package version
+
+import (
+	"slices"
+	"testing"
+)
+
+// Sources keeping version in files must report them, e.g. to `find`.
+func TestFileSources(t *testing.T) {
+	withoutFiles := []string{"debug", "git", "plugin", "tool"}
+	for srcType, constructor := range sources {
+		_, ok := constructor().(FileSource)
+		if ok == slices.Contains(withoutFiles, srcType) {
+			t.Errorf("%s source implements FileSource: %t", srcType, ok)
+		}
+	}
+}
+
+func TestSourceFiles(t *testing.T) {
+	fs := testFS(t, map[string]string{
+		"flake.nix":           "{ version = builtins.readFile ./VERSION; }\n",
+		"VERSION":             "1.2.0\n",
+		"Cargo.toml":          "[package]\nname = \"a\"\nversion = \"1.2.0\"\n",
+		"Cargo.lock":          "",
+		"charts/a/Chart.yaml": "name: a\nversion: 1.2.0\n",
+		"charts/b/Chart.yaml": "name: b\nversion: 1.0.0\n",
+		"debian/changelog":    "",
+	})
+	tests := []struct {
+		src   FileSource
+		files []string
+	}{
+		{&JSONSource{Path: "*.json"}, []string{}},
+		{&NixSource{Path: "flake.nix", KeyPath: []string{"version"}}, []string{"flake.nix", "VERSION"}},
+		{&CargoLockSource{Path: "Cargo.lock", Manifest: "Cargo.toml"}, []string{"Cargo.lock", "Cargo.toml"}},
+		{
+			&HelmSource{Path: "charts/a/Chart.yaml", Dependents: []string{"charts/*/Chart.yaml"}},
+			[]string{"charts/a/Chart.yaml", "charts/b/Chart.yaml"},
+		},
+		// Default path
+		{&DebChangelogSource{}, []string{"debian/changelog"}},
+	}
+	for _, tt := range tests {
+		files, err := tt.src.Files(fs)
+		if err != nil || !slices.Equal(files, tt.files) {
+			t.Errorf("%T.Files() = %q, %v, want %q", tt.src, files, err, tt.files)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package version
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Change made (or planned in dry run) by `sync`.
+type SyncChange struct {
+	Name Name
+	// Previous version, nil if source reported none
+	From *semver.Version
+	To   *semver.Version
+}
+
+// Sync writes version of the most authoritative source (see Priority) to
+// other writable sources reporting lesser or no version.
+// Sources ahead of authoritative one are never downgraded and make Sync
+// fail after all other sources are written.
+// Like Set, it tags current commit if writable Git source lags.
+func (g *SourceGroup) Sync(names []Name, dryRun bool) ([]SyncChange, error) {
+	g.Log("fetching versions from sources...")
+	reports, _, err := g.Fetch(names)
+	if err != nil {
+		return nil, err
+	}
+	ref := g.authoritative(reports)
+	if ref == nil {
+		return nil, errors.New("no version found in sources")
+	}
+	auth := *ref
+	g.Log(fmt.Sprintf(
+		"  %s is authoritative with version: %s", auth.Name, g.Format(auth.Version),
+	))
+	slices.SortFunc(reports, func(a, b Report) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	changes := []SyncChange{}
+	for _, r := range reports {
+		if r.Name == auth.Name {
+			continue
+		}
+		if r.Version != nil && partiallyEqual(auth.Version, r.Version, r.Meta.parts()) {
+			g.Trace(fmt.Sprintf("  %s: in sync", r.Name))
+			continue
+		}
+		if r.Version != nil && r.Version.GreaterThan(auth.Version) {
+			g.Err(fmt.Sprintf(
+				"  %s reports version %s ahead of authoritative one",
+				r.Name, g.Format(r.Version),
+			))
+			if err == nil {
+				err = errors.New("  some sources are ahead of authoritative one")
+			}
+			continue
+		}
+		if r.Meta.Source.IsReadOnly() {
+			g.Log(fmt.Sprintf("  %s lags but skipped as readonly", r.Name))
+			continue
+		}
+		if !dryRun {
+			e := g.setSource(r.Meta, *auth.Version)
+			if errors.Is(e, ErrNoChanges) {
+				g.Trace(fmt.Sprintf("  %s: no changes", r.Name))
+				continue
+			}
+			if e != nil {
+				g.Err(fmt.Sprintf("  %s failed with: %s", r.Name, e))
+				if err == nil {
+					err = e
+				}
+				continue
+			}
+		}
+		changes = append(changes, SyncChange{r.Name, r.Version, auth.Version})
+	}
+	return changes, err
+}
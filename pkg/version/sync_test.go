@@ -0,0 +1,84 @@
+package version
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Source keeping version in memory.
+type memSource struct {
+	version   *semver.Version
+	noChanges bool
+	written   bool
+}
+
+func (s *memSource) IsCanBeLesser() bool { return false }
+func (s *memSource) IsReadOnly() bool    { return false }
+
+func (s *memSource) Get(_ FS) (*semver.Version, error) {
+	return s.version, nil
+}
+
+func (s *memSource) Set(v semver.Version, _ FS) error {
+	if s.noChanges {
+		return ErrNoChanges
+	}
+	s.version = &v
+	s.written = true
+	return nil
+}
+
+func TestSync(t *testing.T) {
+	auth := &memSource{version: semver.MustParse("1.3.0")}
+	lagging := &memSource{version: semver.MustParse("1.2.0")}
+	synced := &memSource{version: semver.MustParse("1.3.0")}
+	// E.g. file without version key
+	unchanged := &memSource{noChanges: true}
+	nop := func(string) {}
+	g, err := NewGroupSource("", map[Name]SourceWithMeta{
+		"Auth":      {Priority: 1, Source: auth},
+		"Lagging":   {Source: lagging},
+		"Synced":    {Source: synced},
+		"Unchanged": {Source: unchanged},
+	}, false, nop, nop, nop, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes, err := g.Sync(nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []Name{}
+	for _, c := range changes {
+		names = append(names, c.Name)
+	}
+	if !slices.Equal(names, []Name{"Lagging"}) {
+		t.Errorf("changed sources = %v, want [Lagging]", names)
+	}
+	if !lagging.written || !lagging.version.Equal(auth.version) {
+		t.Errorf("Lagging = %s, want %s", lagging.version, auth.version)
+	}
+	if synced.written || unchanged.written {
+		t.Error("sources in sync were written")
+	}
+}
+
+func TestSyncDoesNotDowngrade(t *testing.T) {
+	ahead := &memSource{version: semver.MustParse("2.0.0")}
+	nop := func(string) {}
+	g, err := NewGroupSource("", map[Name]SourceWithMeta{
+		"Auth":  {Priority: 1, Source: &memSource{version: semver.MustParse("1.3.0")}},
+		"Ahead": {Source: ahead},
+	}, false, nop, nop, nop, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Sync(nil, false); err == nil {
+		t.Error("Sync succeeded with source ahead of authoritative one")
+	}
+	if ahead.written {
+		t.Error("source ahead of authoritative one was downgraded")
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package version
 
 import (
 	"github.com/Masterminds/semver/v3"
@@ -9,18 +9,19 @@ func init() {
 	RegisterSource("toml", func() Source { return &TOMLSource{} })
 	RegisterDefaultSource("PyProject", SourceWithMeta{
 		VPrefix: VPrefixFalse,
+		Dialect: "pep440",
 		Source: &TOMLSource{
 			"pyproject.toml",
 			[]string{"project", "version"},
 		},
-	})
+	}, "pyproject.toml")
 	RegisterDefaultSource("Cargo", SourceWithMeta{
 		VPrefix: VPrefixFalse,
 		Source: &TOMLSource{
 			"Cargo.toml",
 			[]string{"package", "version"},
 		},
-	})
+	}, "Cargo.toml")
 }
 
 type TOMLSource struct {
@@ -36,6 +37,14 @@ func (d *TOMLSource) IsReadOnly() bool {
 	return false
 }
 
+func (d *TOMLSource) RequiredOptions() []string {
+	return []string{"Path", "KeyPath"}
+}
+
+func (d *TOMLSource) Files(fs FS) ([]string, error) {
+	return globFiles(fs, d.Path)
+}
+
 func (d *TOMLSource) Get(fs FS) (*semver.Version, error) {
 	return getFromDoc(fs, toml.New, d.KeyPath, d.Path)
 }
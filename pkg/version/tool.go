@@ -1,4 +1,4 @@
-package main
+package version
 
 import (
 	"bytes"
@@ -82,24 +82,28 @@ func (d *ToolSource) IsReadOnly() bool {
 	return true
 }
 
+func (d *ToolSource) RequiredOptions() []string {
+	return []string{"Cmd"}
+}
+
 func (d *ToolSource) Set(_ semver.Version, _ FS) error {
 	return nil // Read Only
 }
 
-func (d *ToolSource) Get(_ FS) (*semver.Version, error) {
+func (d *ToolSource) Get(fs FS) (*semver.Version, error) {
 	out, err := d.exec()
 	if err != nil {
 		return nil, err
 	}
 	if len(d.Regexps) == 0 {
-		return semver.NewVersion(strings.TrimSpace(string(out)))
+		return ParseVersion(fs, strings.TrimSpace(string(out)))
 	}
 	doc, err := regexp.New(out)
 	if err != nil {
 		return nil, err
 	}
 	str := doc.Get(d.Regexps)
-	return semver.NewVersion(str)
+	return ParseVersion(fs, str)
 }
 
 func (d *ToolSource) exec() ([]byte, error) {
@@ -0,0 +1,546 @@
+package version
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"path"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/pelletier/go-toml"
+)
+
+// Config keys besides ones handled while merging configs.
+const (
+	defaultVersionKey = "DefaultVersion"
+	schemeKey         = "Scheme"
+	strictKey         = "Strict"
+	typeKey           = "Type"
+	groupsKey         = "Groups"
+	// Lets JSON and YAML configs reference JSON Schema
+	jsonSchemaKey = "$schema"
+)
+
+// Keys of source table handled by SourceWithMeta itself and their types.
+// VPrefix may be bool or integer too.
+var sourceMetaKeys = map[string]reflect.Type{
+	typeKey:    reflect.TypeFor[string](),
+	"VPrefix":  reflect.TypeFor[string](),
+	"Disabled": reflect.TypeFor[bool](),
+	"Dialect":  reflect.TypeFor[string](),
+	"Format":   reflect.TypeFor[string](),
+	"Policy":   reflect.TypeFor[string](),
+	"Priority": reflect.TypeFor[int](),
+}
+
+// RequiredOptionsSource is implemented by sources having options that
+// must be set in config, e.g. Path.
+type RequiredOptionsSource interface {
+	RequiredOptions() []string
+}
+
+// ConfigIssue is a single problem found in config file.
+// Line and Col are 1-based and zero if position is unknown.
+type ConfigIssue struct {
+	File string
+	Line int
+	Col  int
+	// Dot separated path of key, e.g. "Sources.Cargo.Path"
+	Key string
+	Msg string
+}
+
+func (i ConfigIssue) Error() string {
+	var b strings.Builder
+	if i.File != "" {
+		b.WriteString(i.File + ":")
+	}
+	if i.Line > 0 {
+		fmt.Fprintf(&b, "%d:%d:", i.Line, i.Col)
+	}
+	if b.Len() > 0 {
+		b.WriteString(" ")
+	}
+	if i.Key != "" {
+		b.WriteString(i.Key + ": ")
+	}
+	b.WriteString(i.Msg)
+	return b.String()
+}
+
+// ConfigError lists all problems found in config file.
+type ConfigError []ConfigIssue
+
+func (e ConfigError) Error() string {
+	lines := make([]string, len(e))
+	for i, issue := range e {
+		lines[i] = issue.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Prefixes error with config file path unless it is ConfigError
+// already holding file positions.
+func wrapConfigError(path string, err error) error {
+	var cerr ConfigError
+	if errors.As(err, &cerr) {
+		return err
+	}
+	return fmt.Errorf("%s: %w", path, err)
+}
+
+// Returns line and column of key at path in config file
+// or of its closest known parent.
+type configLocator = func(keys []string) (int, int)
+
+func noLocator(_ []string) (int, int) { return 0, 0 }
+
+// Returns locator for config file data at path, see configTree.
+// Keys are looked up under subtree.
+func newConfigLocator(data []byte, path, subtree string) configLocator {
+	var prefix []string
+	if subtree != "" {
+		prefix = strings.Split(subtree, ".")
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".yaml", ".yml":
+		// JSON is YAML too
+		file, err := parser.ParseBytes(data, 0)
+		if err != nil || len(file.Docs) == 0 {
+			return noLocator
+		}
+		body := file.Docs[0].Body
+		return func(keys []string) (int, int) {
+			return yamlPosition(body, slices.Concat(prefix, keys))
+		}
+	}
+	tree, err := toml.LoadBytes(data)
+	if err != nil {
+		return noLocator
+	}
+	return func(keys []string) (int, int) {
+		keys = slices.Concat(prefix, keys)
+		for n := len(keys); n > len(prefix); n-- {
+			pos := tree.GetPositionPath(keys[:n])
+			if !pos.Invalid() {
+				return pos.Line, pos.Col
+			}
+		}
+		return 0, 0
+	}
+}
+
+func yamlPosition(node ast.Node, keys []string) (int, int) {
+	line, col := 0, 0
+	for _, key := range keys {
+		var values []*ast.MappingValueNode
+		switch n := node.(type) {
+		case *ast.MappingNode:
+			values = n.Values
+		case *ast.MappingValueNode:
+			values = []*ast.MappingValueNode{n}
+		}
+		i := slices.IndexFunc(values, func(v *ast.MappingValueNode) bool {
+			return v.Key.GetToken().Value == key
+		})
+		if i < 0 {
+			break
+		}
+		pos := values[i].Key.GetToken().Position
+		line, col = pos.Line, pos.Column
+		node = values[i].Value
+	}
+	return line, col
+}
+
+// Checks config of single file before it is merged with other ones.
+// DefaultVersion is checked after merge, see checkDefaultVersion.
+type configValidator struct {
+	file   string
+	locate configLocator
+	issues ConfigError
+}
+
+func (v *configValidator) add(keys []string, format string, args ...any) {
+	line, col := v.locate(keys)
+	v.issues = append(v.issues, ConfigIssue{
+		File: v.file,
+		Line: line,
+		Col:  col,
+		Key:  strings.Join(keys, "."),
+		Msg:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Returns ConfigError with all problems of config or nil.
+func validateConfig(m map[string]any, file string, locate configLocator) error {
+	v := &configValidator{file: file, locate: locate}
+	v.config(m)
+	slices.SortStableFunc(v.issues, func(a, b ConfigIssue) int {
+		return cmp.Or(cmp.Compare(a.Line, b.Line), cmp.Compare(a.Col, b.Col))
+	})
+	if len(v.issues) > 0 {
+		return v.issues
+	}
+	return nil
+}
+
+func (v *configValidator) config(m map[string]any) {
+	for _, key := range slices.Sorted(maps.Keys(m)) {
+		val := m[key]
+		keys := []string{key}
+		switch {
+		case strings.EqualFold(key, defaultVersionKey):
+			v.expect(keys, val, reflect.TypeFor[string]())
+		case strings.EqualFold(key, schemeKey):
+			v.scheme(keys, val)
+		case strings.EqualFold(key, strictKey):
+			v.expect(keys, val, reflect.TypeFor[bool]())
+		case strings.EqualFold(key, ignoredFilesKey),
+			strings.EqualFold(key, readOnlyFilesKey):
+			if v.expect(keys, val, reflect.TypeFor[[]string]()) {
+				for _, glob := range val.([]any) {
+					v.glob(keys, glob.(string))
+				}
+			}
+		case strings.EqualFold(key, extendsKey),
+			strings.EqualFold(key, includeKey):
+			if _, ok := val.(string); !ok {
+				v.expect(keys, val, reflect.TypeFor[[]string]())
+			}
+		case strings.EqualFold(key, groupsKey):
+			v.expect(keys, val, reflect.TypeFor[map[string][]string]())
+		case key == jsonSchemaKey:
+			v.expect(keys, val, reflect.TypeFor[string]())
+		case strings.EqualFold(key, sourcesKey):
+			srcs, ok := val.(map[string]any)
+			if !ok {
+				v.add(keys, "expected table, got %s", valueKind(val))
+				continue
+			}
+			for _, name := range slices.Sorted(maps.Keys(srcs)) {
+				v.source([]string{key, name}, srcs[name])
+			}
+		default:
+			v.add(keys, "unknown key")
+		}
+	}
+}
+
+func (v *configValidator) scheme(keys []string, val any) {
+	m, ok := val.(map[string]any)
+	if !ok {
+		if typ, ok := val.(string); ok {
+			m = map[string]any{typeKey: typ}
+		} else {
+			v.add(keys, "expected string or table, got %s", valueKind(val))
+			return
+		}
+	}
+	tk, ok := findKey(m, typeKey)
+	if !ok {
+		v.add(keys, "missing Type")
+		return
+	}
+	typ, ok := m[tk].(string)
+	if !ok {
+		v.add(append(keys, tk), "expected string, got %s", valueKind(m[tk]))
+		return
+	}
+	constructor, ok := schemes[strings.ToLower(typ)]
+	if !ok {
+		v.add(append(keys, tk), "unknown scheme type %q", typ)
+		return
+	}
+	if _, isTable := val.(map[string]any); isTable {
+		v.options(keys, m, reflect.TypeOf(constructor()), []string{typeKey})
+	}
+}
+
+func (v *configValidator) source(keys []string, val any) {
+	m, ok := val.(map[string]any)
+	if !ok {
+		v.add(keys, "expected table, got %s", valueKind(val))
+		return
+	}
+	for _, key := range slices.Sorted(maps.Keys(m)) {
+		val := m[key]
+		keys := append(slices.Clone(keys), key)
+		switch {
+		case strings.EqualFold(key, "VPrefix"):
+			switch val.(type) {
+			case string, bool:
+			default:
+				v.expect(keys, val, reflect.TypeFor[int]())
+			}
+		case strings.EqualFold(key, "Disabled"):
+			v.expect(keys, val, reflect.TypeFor[bool]())
+		case strings.EqualFold(key, "Dialect"):
+			if v.expect(keys, val, reflect.TypeFor[string]()) {
+				if _, ok := dialects[strings.ToLower(val.(string))]; !ok {
+					v.add(keys, "unknown version dialect %q", val)
+				}
+			}
+		case strings.EqualFold(key, "Format"):
+			if v.expect(keys, val, reflect.TypeFor[string]()) {
+				if _, err := parseTemplate(val.(string)); err != nil {
+					v.add(keys, "%s", err)
+				}
+			}
+		case strings.EqualFold(key, "Policy"):
+			if v.expect(keys, val, reflect.TypeFor[string]()) {
+				if _, err := parsePolicy(val.(string)); err != nil {
+					v.add(keys, "%s", err)
+				}
+			}
+		case strings.EqualFold(key, "Priority"):
+			v.expect(keys, val, reflect.TypeFor[int]())
+		}
+	}
+	tk, ok := findKey(m, typeKey)
+	if !ok {
+		v.add(keys, "missing Type")
+		return
+	}
+	typ, ok := m[tk].(string)
+	if !ok {
+		v.add(append(keys, tk), "expected string, got %s", valueKind(m[tk]))
+		return
+	}
+	constructor, ok := sources[typ]
+	if !ok {
+		v.add(append(keys, tk), "unknown source type %q", typ)
+		return
+	}
+	src := constructor()
+	v.options(keys, m, reflect.TypeOf(src), slices.Collect(maps.Keys(sourceMetaKeys)))
+	if req, ok := src.(RequiredOptionsSource); ok {
+		for _, opt := range req.RequiredOptions() {
+			k, ok := findKey(m, opt)
+			if !ok || isEmptyValue(m[k]) {
+				v.add(keys, "missing %s for %s source", opt, typ)
+			}
+		}
+	}
+}
+
+// Checks options of table m against exported fields of struct typ.
+// Keys from skip are handled by caller.
+func (v *configValidator) options(
+	keys []string,
+	m map[string]any,
+	typ reflect.Type,
+	skip []string,
+) {
+	fields := optionFields(typ)
+	for _, key := range slices.Sorted(maps.Keys(m)) {
+		if slices.ContainsFunc(skip, func(s string) bool {
+			return strings.EqualFold(s, key)
+		}) {
+			continue
+		}
+		keys := append(slices.Clone(keys), key)
+		i := slices.IndexFunc(fields, func(f optionField) bool {
+			return strings.EqualFold(f.name, key)
+		})
+		if i < 0 {
+			v.add(keys, "unknown key")
+			continue
+		}
+		if !v.expect(keys, m[key], fields[i].typ) {
+			continue
+		}
+		switch fields[i].name {
+		case "Path", "Manifest":
+			v.glob(keys, m[key].(string))
+		case "Dependents":
+			for _, glob := range m[key].([]any) {
+				v.glob(keys, glob.(string))
+			}
+		}
+	}
+}
+
+// Reports glob that is not a valid pattern.
+func (v *configValidator) glob(keys []string, glob string) {
+	if _, err := path.Match(glob, ""); err != nil {
+		v.add(keys, "invalid glob %q: %s", glob, err)
+	}
+}
+
+// Reports value that does not fit type, returns whether it fits.
+func (v *configValidator) expect(keys []string, val any, typ reflect.Type) bool {
+	if fitsType(val, typ) {
+		return true
+	}
+	v.add(keys, "expected %s, got %s", typeKind(typ), valueKind(val))
+	return false
+}
+
+// Reports DefaultVersion of merged config not parsable with group scheme.
+func checkDefaultVersion(
+	gs *SourceGroup,
+	m map[string]any,
+	file string,
+	locate configLocator,
+) error {
+	if _, err := gs.ParseDefaultVersion(); err != nil {
+		key, _ := findKey(m, defaultVersionKey)
+		v := &configValidator{file: file, locate: locate}
+		v.add([]string{key}, "invalid version %q: %s", gs.DefaultVersion, err)
+		return v.issues
+	}
+	return nil
+}
+
+// Config option backed by struct field.
+type optionField struct {
+	name  string
+	index int
+	typ   reflect.Type
+}
+
+// Returns options of struct (or pointer to struct) type named like its
+// exported fields or their toml tags.
+func optionFields(typ reflect.Type) []optionField {
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+	fields := []optionField{}
+	for i := range typ.NumField() {
+		f := typ.Field(i)
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("toml"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, optionField{name, i, f.Type})
+	}
+	return fields
+}
+
+// Reports whether config value may be decoded into type.
+func fitsType(val any, typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.String:
+		_, ok := val.(string)
+		return ok
+	case reflect.Bool:
+		_, ok := val.(bool)
+		return ok
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch n := val.(type) {
+		case int, int64, uint64:
+			return true
+		case float64:
+			return n == float64(int64(n))
+		}
+		return false
+	case reflect.Float32, reflect.Float64:
+		switch val.(type) {
+		case int, int64, uint64, float64:
+			return true
+		}
+		return false
+	case reflect.Slice:
+		list, ok := val.([]any)
+		if !ok {
+			return false
+		}
+		for _, item := range list {
+			if !fitsType(item, typ.Elem()) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		m, ok := val.(map[string]any)
+		if !ok {
+			return false
+		}
+		for _, item := range m {
+			if !fitsType(item, typ.Elem()) {
+				return false
+			}
+		}
+		return true
+	}
+	return true
+}
+
+// Returns name of type as it is spelled in config.
+func typeKind(typ reflect.Type) string {
+	switch typ.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice:
+		return "array of " + typeKind(typ.Elem()) + "s"
+	case reflect.Map:
+		if typ.Elem().Kind() == reflect.Interface {
+			return "table"
+		}
+		return "table of " + typeKind(typ.Elem()) + "s"
+	}
+	return "value"
+}
+
+// Returns name of config value type.
+func valueKind(val any) string {
+	switch val := val.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case int, int64, uint64:
+		return "integer"
+	case float64:
+		if val == float64(int64(val)) {
+			return "integer"
+		}
+		return "float"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "table"
+	case time.Time:
+		return "datetime"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", val)
+}
+
+func isEmptyValue(val any) bool {
+	switch val := val.(type) {
+	case string:
+		return val == ""
+	case []any:
+		return len(val) == 0
+	case nil:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,560 @@
+package version
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/asciimoth/inplace/json"
+	"github.com/asciimoth/rewrite"
+	"github.com/creachadair/tomledit"
+	"github.com/creachadair/tomledit/parser"
+	"github.com/goccy/go-yaml"
+	"github.com/pelletier/go-toml"
+)
+
+// Single comparator version requirement like "^1.2", "=1.2.3" or
+// "workspace:~1.2.3". Wildcards like "1.x" are not matched.
+var requirementRegexp = regexp.MustCompile(
+	`^((?:workspace:)?\s*(?:[=^~]|>=|<=|>|<)?\s*)` +
+		`v?\d+(?:\.\d+){0,2}(?:[-+][0-9A-Za-z.+-]*)?$`,
+)
+
+// Dependency tables of Cargo.toml.
+var cargoDepTables = []string{
+	"dependencies",
+	"dev-dependencies",
+	"build-dependencies",
+}
+
+// Dependency tables of package.json.
+var npmDepTables = []string{
+	"dependencies",
+	"devDependencies",
+	"peerDependencies",
+	"optionalDependencies",
+}
+
+func init() {
+	RegisterSource(
+		"cargoworkspace",
+		func() Source { return &CargoWorkspaceSource{} },
+	)
+	RegisterSource(
+		"npmworkspace",
+		func() Source { return &NpmWorkspaceSource{} },
+	)
+}
+
+// CargoWorkspaceSource handles `workspace.package.version` and
+// `package.version` of root and member crates of Cargo workspace.
+// Members with `version.workspace = true` inherit root version.
+// On Set version requirements of intra-workspace dependencies are updated too.
+type CargoWorkspaceSource struct {
+	Path string
+}
+
+func (d *CargoWorkspaceSource) IsCanBeLesser() bool {
+	return false
+}
+
+func (d *CargoWorkspaceSource) IsReadOnly() bool {
+	return false
+}
+
+func (d *CargoWorkspaceSource) RequiredOptions() []string {
+	return []string{"Path"}
+}
+
+func (d *CargoWorkspaceSource) Get(fs FS) (*semver.Version, error) {
+	manifests, err := cargoWorkspaceManifests(fs, d.Path)
+	if err != nil {
+		return nil, err
+	}
+	var v *semver.Version
+	for _, manifest := range manifests {
+		data, err := rewrite.Read(fs, manifest)
+		if err != nil {
+			return nil, err
+		}
+		doc, err := tomledit.Parse(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		for _, kp := range [][]string{
+			{"workspace", "package", "version"},
+			{"package", "version"},
+		} {
+			entry := doc.First(kp...)
+			if entry == nil || entry.KeyValue == nil {
+				continue
+			}
+			val, ok := tomlUnquote(entry.Value)
+			if !ok {
+				continue // E.g. `version = { workspace = true }`
+			}
+			cv, err := ParseVersion(fs, val)
+			if err != nil {
+				return nil, err
+			}
+			if v != nil && !v.Equal(cv) {
+				return nil, errUnsync
+			}
+			v = cv
+		}
+	}
+	return v, nil
+}
+
+func (d *CargoWorkspaceSource) Files(fs FS) ([]string, error) {
+	return cargoWorkspaceManifests(fs, d.Path)
+}
+
+func (d *CargoWorkspaceSource) Set(v semver.Version, fs FS) error {
+	manifests, err := cargoWorkspaceManifests(fs, d.Path)
+	if err != nil {
+		return err
+	}
+	names, err := namesFromManifests(fs, manifests)
+	if err != nil {
+		return err
+	}
+	val := FormatVersion(fs, &v)
+	changes := false
+	for _, manifest := range manifests {
+		data, err := rewrite.Read(fs, manifest)
+		if err != nil {
+			return err
+		}
+		doc, err := tomledit.Parse(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		// Other than version related lines stay byte-for-byte unchanged
+		lines := bytes.SplitAfter(data, []byte("\n"))
+		changed := false
+		doc.Scan(func(key parser.Key, e *tomledit.Entry) bool {
+			if e.KeyValue == nil {
+				return true
+			}
+			old, ok := tomlUnquote(e.Value)
+			if !ok {
+				return true
+			}
+			nval := ""
+			switch {
+			case key.Equals(parser.Key{"workspace", "package", "version"}),
+				key.Equals(parser.Key{"package", "version"}):
+				nval = val
+			case isCargoDependency(doc, key, names):
+				nval, ok = updateRequirement(old, val)
+				if !ok {
+					return true
+				}
+			default:
+				return true
+			}
+			if nval == old {
+				return true
+			}
+			if replaceTomlValue(
+				lines, e.Line, e.Name[len(e.Name)-1], old, nval,
+			) {
+				changed = true
+			}
+			return true
+		})
+		if !changed {
+			continue
+		}
+		err = rewrite.Write(fs, manifest, bytes.Join(lines, nil))
+		if err != nil {
+			return err
+		}
+		changes = true
+	}
+	if changes {
+		return nil
+	}
+	return ErrNoChanges
+}
+
+// Returns root manifest followed by manifests of all workspace members.
+func cargoWorkspaceManifests(fs FS, root string) ([]string, error) {
+	files, err := fs.Glob(root)
+	if err != nil {
+		return nil, err
+	}
+	manifests := []string{}
+	for _, file := range files {
+		data, err := rewrite.Read(fs, file)
+		if err != nil {
+			continue
+		}
+		tree, err := toml.LoadBytes(data)
+		if err != nil {
+			return nil, err
+		}
+		manifests = appendUnique(manifests, file)
+		members, err := expandMembers(
+			fs,
+			path.Dir(file),
+			tomlStrings(tree.Get("workspace.members")),
+			tomlStrings(tree.Get("workspace.exclude")),
+			"Cargo.toml",
+		)
+		if err != nil {
+			return nil, err
+		}
+		manifests = appendUnique(manifests, members...)
+	}
+	return manifests, nil
+}
+
+// Reports whether key points to a version requirement of dependency on
+// one of named crates, e.g. `dependencies.name.version`,
+// `workspace.dependencies.name.version` or `target.X.dev-dependencies.name`.
+func isCargoDependency(
+	doc *tomledit.Document,
+	key parser.Key,
+	names []string,
+) bool {
+	var table, crate parser.Key
+	switch {
+	case len(key) >= 2 && slices.Contains(cargoDepTables, key[len(key)-2]):
+		table, crate = key[:len(key)-1], key[len(key)-1:]
+	case len(key) >= 3 && key[len(key)-1] == "version" &&
+		slices.Contains(cargoDepTables, key[len(key)-3]):
+		table, crate = key[:len(key)-2], key[len(key)-2:len(key)-1]
+	default:
+		return false
+	}
+	switch {
+	case len(table) == 1:
+	case len(table) == 2 && table[0] == "workspace":
+	case len(table) == 3 && table[0] == "target":
+	default:
+		return false
+	}
+	name := crate[0]
+	// Renamed dependency, e.g. `alias = { package = "name", ... }`
+	pkg := append(slices.Clone(table), name, "package")
+	if entry := doc.First(pkg...); entry != nil && entry.KeyValue != nil {
+		if real, ok := tomlUnquote(entry.Value); ok {
+			name = real
+		}
+	}
+	return slices.Contains(names, name)
+}
+
+func namesFromManifests(fs FS, manifests []string) ([]string, error) {
+	names := []string{}
+	for _, manifest := range manifests {
+		n, err := namesFromManifest(fs, manifest, [][]string{
+			{"package", "name"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, n...)
+	}
+	return names, nil
+}
+
+// NpmWorkspaceSource handles `version` of npm/yarn/pnpm workspace members
+// listed in `workspaces` of root package.json or in pnpm-workspace.yaml.
+// Root package itself is handled only if IncludeRoot is set.
+// On Set version requirements of intra-workspace dependencies are updated too.
+type NpmWorkspaceSource struct {
+	Path        string
+	IncludeRoot bool
+}
+
+func (d *NpmWorkspaceSource) IsCanBeLesser() bool {
+	return false
+}
+
+func (d *NpmWorkspaceSource) IsReadOnly() bool {
+	return false
+}
+
+func (d *NpmWorkspaceSource) RequiredOptions() []string {
+	return []string{"Path"}
+}
+
+func (d *NpmWorkspaceSource) Get(fs FS) (*semver.Version, error) {
+	roots, members, err := d.manifests(fs)
+	if err != nil {
+		return nil, err
+	}
+	if d.IncludeRoot {
+		members = append(roots, members...)
+	}
+	var v *semver.Version
+	for _, manifest := range members {
+		cv, err := getFromDoc(fs, json.New, []string{"version"}, manifest)
+		if err != nil {
+			return nil, err
+		}
+		if cv == nil {
+			continue
+		}
+		if v != nil && !v.Equal(cv) {
+			return nil, errUnsync
+		}
+		v = cv
+	}
+	return v, nil
+}
+
+func (d *NpmWorkspaceSource) Files(fs FS) ([]string, error) {
+	roots, members, err := d.manifests(fs)
+	if err != nil {
+		return nil, err
+	}
+	return append(roots, members...), nil
+}
+
+func (d *NpmWorkspaceSource) Set(v semver.Version, fs FS) error {
+	roots, members, err := d.manifests(fs)
+	if err != nil {
+		return err
+	}
+	versioned := members
+	if d.IncludeRoot {
+		versioned = append(slices.Clone(roots), members...)
+	}
+	names := []string{}
+	for _, manifest := range versioned {
+		pkg, err := readPackageJSON(fs, manifest)
+		if err != nil {
+			return err
+		}
+		if pkg.Name != "" {
+			names = append(names, pkg.Name)
+		}
+	}
+	val := FormatVersion(fs, &v)
+	changes := false
+	for _, manifest := range appendUnique(roots, members...) {
+		pkg, err := readPackageJSON(fs, manifest)
+		if err != nil {
+			return err
+		}
+		data, err := rewrite.Read(fs, manifest)
+		if err != nil {
+			return err
+		}
+		doc, err := json.NewHuJSON(data)
+		if err != nil {
+			return err
+		}
+		changed := false
+		if pkg.Version != "" && pkg.Version != val &&
+			slices.Contains(versioned, manifest) {
+			if err := doc.Set([]string{"version"}, val); err != nil {
+				return err
+			}
+			changed = true
+		}
+		for table, deps := range pkg.deps() {
+			for _, name := range names {
+				req, ok := deps[name]
+				if !ok {
+					continue
+				}
+				nreq, ok := updateRequirement(req, val)
+				if !ok {
+					continue
+				}
+				err := doc.Set([]string{table, name}, nreq)
+				if err != nil {
+					return err
+				}
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		err = rewrite.Write(fs, manifest, doc.Save())
+		if err != nil {
+			return err
+		}
+		changes = true
+	}
+	if changes {
+		return nil
+	}
+	return ErrNoChanges
+}
+
+// Returns root manifests and manifests of all workspace members.
+func (d *NpmWorkspaceSource) manifests(fs FS) ([]string, []string, error) {
+	roots, err := fs.Glob(d.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+	members := []string{}
+	for _, root := range roots {
+		pkg, err := readPackageJSON(fs, root)
+		if err != nil {
+			return nil, nil, err
+		}
+		patterns := pkg.workspaces()
+		pnpm := path.Join(path.Dir(root), "pnpm-workspace.yaml")
+		if data, err := rewrite.Read(fs, pnpm); err == nil {
+			var ws struct {
+				Packages []string `yaml:"packages"`
+			}
+			if err := yaml.Unmarshal(data, &ws); err != nil {
+				return nil, nil, err
+			}
+			patterns = append(patterns, ws.Packages...)
+		}
+		include := []string{}
+		exclude := []string{}
+		for _, pattern := range patterns {
+			if p, ok := strings.CutPrefix(pattern, "!"); ok {
+				exclude = append(exclude, p)
+				continue
+			}
+			include = append(include, pattern)
+		}
+		m, err := expandMembers(
+			fs, path.Dir(root), include, exclude, "package.json",
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		members = appendUnique(members, m...)
+	}
+	members = slices.DeleteFunc(members, func(m string) bool {
+		return slices.Contains(roots, m)
+	})
+	return roots, members, nil
+}
+
+type packageJSON struct {
+	Name                 string             `json:"name"`
+	Version              string             `json:"version"`
+	Workspaces           stdjson.RawMessage `json:"workspaces"`
+	Dependencies         map[string]string  `json:"dependencies"`
+	DevDependencies      map[string]string  `json:"devDependencies"`
+	PeerDependencies     map[string]string  `json:"peerDependencies"`
+	OptionalDependencies map[string]string  `json:"optionalDependencies"`
+}
+
+func readPackageJSON(fs FS, manifest string) (*packageJSON, error) {
+	data, err := rewrite.Read(fs, manifest)
+	if err != nil {
+		return nil, err
+	}
+	var pkg packageJSON
+	if err := stdjson.Unmarshal(data, &pkg); err != nil {
+		return nil, err
+	}
+	return &pkg, nil
+}
+
+// Workspaces can be either an array of globs (npm, yarn) or an object
+// with `packages` array (yarn classic).
+func (p *packageJSON) workspaces() []string {
+	var list []string
+	if stdjson.Unmarshal(p.Workspaces, &list) == nil {
+		return list
+	}
+	var obj struct {
+		Packages []string `json:"packages"`
+	}
+	if stdjson.Unmarshal(p.Workspaces, &obj) == nil {
+		return obj.Packages
+	}
+	return nil
+}
+
+func (p *packageJSON) deps() map[string]map[string]string {
+	return map[string]map[string]string{
+		npmDepTables[0]: p.Dependencies,
+		npmDepTables[1]: p.DevDependencies,
+		npmDepTables[2]: p.PeerDependencies,
+		npmDepTables[3]: p.OptionalDependencies,
+	}
+}
+
+// Expands workspace member globs relative to dir into paths of their
+// manifests. Members without manifest are skipped.
+// `**` is treated as `*` since fs.Glob does not support recursive patterns.
+func expandMembers(
+	fs FS,
+	dir string,
+	include, exclude []string,
+	manifest string,
+) ([]string, error) {
+	excluded := func(m string) bool {
+		for _, pattern := range exclude {
+			pattern = path.Join(dir, strings.ReplaceAll(pattern, "**", "*"))
+			if ok, _ := path.Match(pattern, m); ok {
+				return true
+			}
+		}
+		return false
+	}
+	members := []string{}
+	for _, pattern := range include {
+		pattern = path.Join(dir, strings.ReplaceAll(pattern, "**", "*"))
+		matches, err := fs.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			if excluded(m) {
+				continue
+			}
+			file := path.Join(m, manifest)
+			if _, err := fs.Stat(file); err != nil {
+				continue
+			}
+			members = appendUnique(members, file)
+		}
+	}
+	return members, nil
+}
+
+// Replaces version in single comparator requirement keeping its operator,
+// e.g. "^1.2" -> "^2.0.0". Wildcards and complex ranges are left untouched.
+func updateRequirement(req, val string) (string, bool) {
+	m := requirementRegexp.FindStringSubmatch(req)
+	if m == nil {
+		return "", false
+	}
+	nreq := m[1] + strings.TrimPrefix(val, "v")
+	return nreq, nreq != req
+}
+
+func tomlStrings(val any) []string {
+	list, ok := val.([]any)
+	if !ok {
+		return nil
+	}
+	strs := make([]string, 0, len(list))
+	for _, item := range list {
+		if s, ok := item.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+	return strs
+}
+
+func appendUnique(list []string, items ...string) []string {
+	for _, item := range items {
+		if !slices.Contains(list, item) {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,103 @@
+package version
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Creates files in temporary directory and returns FS rooted at it.
+func testFS(t *testing.T, files map[string]string) FS { //nolint:ireturn
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { root.Close() })
+	return FSFromRoot(root)
+}
+
+// Setting version already stored in sources must report ErrNoChanges,
+// setting new one must not.
+func TestSetReportsNoChanges(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   Source
+		files map[string]string
+	}{
+		{
+			name: "helm",
+			src: &HelmSource{
+				Path:       "chart/Chart.yaml",
+				Coupling:   HelmCouplingSync,
+				Dependents: []string{"app/Chart.yaml"},
+			},
+			files: map[string]string{
+				"chart/Chart.yaml": "name: lib\nversion: 1.2.0\nappVersion: \"1.2.0\"\n",
+				"app/Chart.yaml": "name: app\nversion: 0.1.0\n" +
+					"dependencies:\n  - name: lib\n    version: ^1.2.0\n",
+			},
+		},
+		{
+			name: "helm minor coupling",
+			src:  &HelmSource{Path: "Chart.yaml", Coupling: HelmCouplingMinor},
+			files: map[string]string{
+				"Chart.yaml": "name: lib\nversion: 1.2.0\nappVersion: 3.1.0\n",
+			},
+		},
+		{
+			name: "npm workspace",
+			src:  &NpmWorkspaceSource{Path: "package.json", IncludeRoot: true},
+			files: map[string]string{
+				"package.json": `{"name": "root", "version": "1.2.0", ` +
+					`"workspaces": ["packages/*"]}`,
+				"packages/a/package.json": `{"name": "a", "version": "1.2.0"}`,
+				"packages/b/package.json": `{"name": "b", "version": "1.2.0", ` +
+					`"dependencies": {"a": "^1.2.0"}}`,
+			},
+		},
+		{
+			name: "cargo workspace",
+			src:  &CargoWorkspaceSource{Path: "Cargo.toml"},
+			files: map[string]string{
+				"Cargo.toml": "[workspace]\nmembers = [\"a\", \"b\"]\n\n" +
+					"[workspace.package]\nversion = \"1.2.0\"\n",
+				"a/Cargo.toml": "[package]\nname = \"a\"\nversion = \"1.2.0\"\n",
+				"b/Cargo.toml": "[package]\nname = \"b\"\nversion = \"1.2.0\"\n\n" +
+					"[dependencies]\na = { path = \"../a\", version = \"1.2.0\" }\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		fs := testFS(t, tt.files)
+		err := tt.src.Set(*semver.MustParse("1.2.0"), fs)
+		if !errors.Is(err, ErrNoChanges) {
+			t.Errorf("%s: Set of current version = %v, want ErrNoChanges", tt.name, err)
+		}
+		if err := tt.src.Set(*semver.MustParse("1.3.0"), fs); err != nil {
+			t.Errorf("%s: Set of new version: %s", tt.name, err)
+			continue
+		}
+		v, err := tt.src.Get(fs)
+		if err != nil || v.String() != "1.3.0" {
+			t.Errorf("%s: Get after Set = %v, %v, want 1.3.0", tt.name, v, err)
+		}
+		err = tt.src.Set(*semver.MustParse("1.3.0"), fs)
+		if !errors.Is(err, ErrNoChanges) {
+			t.Errorf("%s: repeated Set = %v, want ErrNoChanges", tt.name, err)
+		}
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package version
 
 import (
 	"github.com/Masterminds/semver/v3"
@@ -7,6 +7,13 @@ import (
 
 func init() {
 	RegisterSource("yaml", func() Source { return &YamlSource{} })
+	RegisterDefaultSource("Pubspec", SourceWithMeta{
+		VPrefix: VPrefixFalse,
+		Source: &YamlSource{
+			"pubspec.yaml",
+			[]string{"version"},
+		},
+	}, "pubspec.yaml")
 }
 
 type YamlSource struct {
@@ -22,6 +29,14 @@ func (d *YamlSource) IsReadOnly() bool {
 	return false
 }
 
+func (d *YamlSource) RequiredOptions() []string {
+	return []string{"Path", "KeyPath"}
+}
+
+func (d *YamlSource) Files(fs FS) ([]string, error) {
+	return globFiles(fs, d.Path)
+}
+
 func (d *YamlSource) Get(fs FS) (*semver.Version, error) {
 	return getFromDoc(fs, yaml.New, d.KeyPath, d.Path)
 }
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/asciimoth/rewrite"
+	"github.com/asciimoth/version/pkg/version"
+)
+
+// Files larger than this are not scanned for version occurrences.
+const maxScannedFileSize = 1 << 20
+
+// Max depth of walked project tree.
+const maxWalkDepth = 16
+
+// Dirs never descended into while walking project tree.
+var skippedDirs = []string{
+	".git", ".hg", ".svn", ".direnv", ".venv", "venv", "__pycache__",
+	"node_modules", "vendor", "target", "dist", "build", "result",
+}
+
+// Files with version history, they contain old versions by design.
+var historyFiles = []string{
+	"CHANGELOG*", "CHANGES*", "HISTORY*", "NEWS*", "RELEASES*",
+	"changelog*", "debian/changelog",
+}
+
+// Version occurrence in project file.
+type occurrence struct {
+	file  string
+	line  int // 1-based
+	col   int // 1-based, in bytes
+	text  string
+	match string
+}
+
+// Returns all regular files of project tree in lexical order.
+// Files ignored by .gitignore are skipped.
+func walkFS(fs version.FS) ([]string, error) {
+	files := []string{}
+	var walk func(dir string, depth int, ignore gitignore) error
+	walk = func(dir string, depth int, ignore gitignore) error {
+		ignore = ignore.load(fs, dir)
+		pattern := "*"
+		if dir != "" {
+			pattern = escapeGlob(dir) + "/*"
+		}
+		matches, err := fs.Glob(pattern)
+		if err != nil {
+			return err
+		}
+		for _, m := range matches {
+			info, err := fs.Stat(m)
+			if err != nil || ignore.ignored(m, info.IsDir()) {
+				continue
+			}
+			if !info.IsDir() {
+				if info.Mode().IsRegular() {
+					files = append(files, m)
+				}
+				continue
+			}
+			if depth >= maxWalkDepth ||
+				slices.Contains(skippedDirs, path.Base(m)) {
+				continue
+			}
+			if err := walk(m, depth+1, ignore); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk("", 0, nil); err != nil {
+		return nil, err
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[\`, r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Reports whether file matches any of globs.
+func matchesAny(file string, globs []string) bool {
+	for _, g := range globs {
+		if ok, _ := path.Match(g, file); ok {
+			return true
+		}
+		if ok, _ := path.Match(g, path.Base(file)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Regexp matching any of variants not surrounded by other version-like
+// characters, so "1.2" does not match inside of "1.2.3".
+func variantsRegexp(variants []string) *regexp.Regexp {
+	quoted := make([]string, 0, len(variants))
+	for _, v := range variants {
+		quoted = append(quoted, regexp.QuoteMeta(v))
+	}
+	// Longer variants first to prefer "v1.2.3" over "1.2.3"
+	slices.SortFunc(quoted, func(a, b string) int { return len(b) - len(a) })
+	return regexp.MustCompile(
+		`(?:^|[^0-9A-Za-z.+])(` + strings.Join(quoted, "|") +
+			`)(?:$|[^0-9A-Za-z+.]|\.(?:$|[^0-9]))`,
+	)
+}
+
+// Finds occurrences of version variants in text files.
+// Binary and too large files are skipped.
+func findOccurrences(
+	fs version.FS,
+	files []string,
+	variants []string,
+) ([]occurrence, error) {
+	re := variantsRegexp(variants)
+	found := []occurrence{}
+	for _, file := range files {
+		info, err := fs.Stat(file)
+		if err != nil || info.Size() > maxScannedFileSize {
+			continue
+		}
+		data, err := rewrite.Read(fs, file)
+		if err != nil {
+			continue
+		}
+		if bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0 {
+			continue
+		}
+		n := 0
+		for line := range bytes.Lines(data) {
+			n++
+			text := strings.TrimRight(string(line), "\r\n")
+			for _, m := range re.FindAllStringSubmatchIndex(text, -1) {
+				found = append(found, occurrence{
+					file,
+					n,
+					m[2] + 1,
+					text,
+					text[m[2]:m[3]],
+				})
+			}
+		}
+	}
+	return found, nil
+}
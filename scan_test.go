@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestVariantsRegexp(t *testing.T) {
+	re := variantsRegexp([]string{"1.4.2", "v1.4.2", "1.4", "v1.4"})
+	tests := []struct {
+		text  string
+		match string
+	}{
+		{"version 1.4.2", "1.4.2"},
+		{"tag v1.4.2.", "v1.4.2"},
+		{"docs for 1.4, see", "1.4"},
+		{"docs/v1.4/index", "v1.4"},
+		{"end of sentence 1.4.", "1.4"},
+		{`"1.4.2"`, "1.4.2"},
+		{"1.4.2-rc.1", "1.4.2"},
+		// Other versions sharing prefix
+		{"version 1.4.1", ""},
+		{"version 1.4.20", ""},
+		{"version 11.4.2", ""},
+		{"version 1.4.2.1", ""},
+		{"version 1.4.2+build", ""},
+		{"version 1.40", ""},
+		{"x1.4.2", ""},
+	}
+	for _, tt := range tests {
+		m := re.FindStringSubmatch(tt.text)
+		got := ""
+		if m != nil {
+			got = m[1]
+		}
+		if got != tt.match {
+			t.Errorf("match in %q = %q, want %q", tt.text, got, tt.match)
+		}
+	}
+}
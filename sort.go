@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/asciimoth/version/pkg/version"
+)
+
+// Flags filtering versions of `max`, `min` and `sort`.
+var filterFlags = []string{"no-prerelease", "major=", "constraint=", "-"}
+
+// Returns versions from literals, sources and stdin (if "-" operand
+// provided) passing filters from opts.
+// Second result reports whether versions were filtered or read from stdin,
+// so empty result can't be replaced with default version.
+func collectVersions(
+	group version.SourceGroup,
+	srcs []string,
+	ver []semver.Version,
+	opts cmdOpts,
+) ([]*semver.Version, bool, error) {
+	vs := make([]*semver.Version, 0, len(ver))
+	for _, v := range ver {
+		vs = append(vs, &v)
+	}
+	if len(srcs) > 0 {
+		reports, _, err := group.Fetch(srcs)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, r := range reports {
+			if r.Version != nil {
+				vs = append(vs, r.Version)
+			}
+		}
+	}
+	if slices.Contains(opts.operands, "-") {
+		read, err := readVersions(group, opts.stdin)
+		if err != nil {
+			return nil, false, err
+		}
+		vs = append(vs, read...)
+	}
+	filter, err := versionFilter(opts)
+	if err != nil {
+		return nil, false, err
+	}
+	return slices.DeleteFunc(vs, func(v *semver.Version) bool {
+		return !filter(v)
+	}), filtered(opts), nil
+}
+
+// Reports whether any of filterFlags is provided.
+func filtered(opts cmdOpts) bool {
+	return slices.ContainsFunc(filterFlags, func(flag string) bool {
+		if flag == "-" {
+			return slices.Contains(opts.operands, "-")
+		}
+		return opts.has(strings.TrimSuffix(flag, "="))
+	})
+}
+
+// Reads versions from lines of r, lines that are not versions are skipped.
+func readVersions(group version.SourceGroup, r io.Reader) ([]*semver.Version, error) {
+	if r == nil {
+		return nil, errors.New("stdin is not available")
+	}
+	vs := []*semver.Version{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		v, err := group.VersionScheme().Parse(line)
+		if err != nil {
+			group.Trace(fmt.Sprintf("  %q skipped as not a version", line))
+			continue
+		}
+		vs = append(vs, v)
+	}
+	return vs, scanner.Err()
+}
+
+// Builds predicate from filter flags.
+func versionFilter(opts cmdOpts) (func(*semver.Version) bool, error) {
+	major := -1
+	if val, ok := opts.flags["major"]; ok {
+		m, err := strconv.ParseUint(strings.TrimPrefix(val, "v"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid major version %q", val)
+		}
+		major = int(m) //nolint:gosec
+	}
+	var constraint *semver.Constraints
+	if val, ok := opts.flags["constraint"]; ok {
+		c, err := semver.NewConstraint(val)
+		if err != nil {
+			return nil, err
+		}
+		constraint = c
+	}
+	noPrerelease := opts.has("no-prerelease")
+	return func(v *semver.Version) bool {
+		if noPrerelease && v.Prerelease() != "" {
+			return false
+		}
+		if major >= 0 && v.Major() != uint64(major) {
+			return false
+		}
+		return constraint == nil || constraint.Check(v)
+	}, nil
+}
+
+// Returns the greatest or the lowest of filtered versions.
+// If there are no versions and no filters, DefaultVersion is returned.
+func extremum(
+	group version.SourceGroup,
+	srcs []string,
+	ver []semver.Version,
+	opts cmdOpts,
+	lowest bool,
+) (*semver.Version, error) {
+	vs, filtered, err := collectVersions(group, srcs, ver, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(vs) > 0 {
+		compare := func(a, b *semver.Version) int { return a.Compare(b) }
+		if lowest {
+			return slices.MinFunc(vs, compare), nil
+		}
+		return slices.MaxFunc(vs, compare), nil
+	}
+	if filtered {
+		return nil, errors.New("no version matches filters")
+	}
+	return group.ParseDefaultVersion()
+}
+
+// `min` subcommand handler.
+func cmdMin(
+	group version.SourceGroup,
+	_ []string,
+	srcs []string,
+	ver []semver.Version,
+	opts cmdOpts,
+	out io.Writer,
+) (int, error) {
+	v, err := extremum(group, srcs, ver, opts, true)
+	if err != nil {
+		return 1, err
+	}
+	err = printVersion(group, v, opts, out)
+	if err != nil {
+		return 1, err
+	}
+	return 0, nil
+}
+
+// `sort` subcommand handler.
+func cmdSort(
+	group version.SourceGroup,
+	_ []string,
+	srcs []string,
+	ver []semver.Version,
+	opts cmdOpts,
+	out io.Writer,
+) (int, error) {
+	vs, _, err := collectVersions(group, srcs, ver, opts)
+	if err != nil {
+		return 1, err
+	}
+	slices.SortStableFunc(vs, func(a, b *semver.Version) int {
+		if opts.has("desc") {
+			return b.Compare(a)
+		}
+		return a.Compare(b)
+	})
+	if opts.has("unique") {
+		vs = slices.CompactFunc(vs, func(a, b *semver.Version) bool {
+			return a.Equal(b)
+		})
+	}
+	for _, v := range vs {
+		err := printVersion(group, v, opts, out)
+		if err != nil {
+			return 1, err
+		}
+	}
+	return 0, nil
+}
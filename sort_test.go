@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSort(t *testing.T) {
+	dir := newProject(t, `DefaultVersion = "0.1.0"`+"\n")
+	stdin := "2.0.0\nnot a version\n\n v1.10.0 \n1.2.0-rc.1\n"
+	tests := []struct {
+		args  []string
+		stdin string
+		out   string
+	}{
+		{[]string{"sort", "1.10.0", "1.2.0", "1.9.0"}, "", "1.2.0\n1.9.0\n1.10.0\n"},
+		{[]string{"sort", "--desc", "1.10.0", "1.2.0", "1.9.0"}, "", "1.10.0\n1.9.0\n1.2.0\n"},
+		// Non-version lines are skipped, versions are printed as written
+		{[]string{"sort", "-", "1.2.0"}, stdin, "1.2.0-rc.1\n1.2.0\nv1.10.0\n2.0.0\n"},
+		{[]string{"sort", "--desc", "-", "--no-prerelease"}, stdin, "2.0.0\nv1.10.0\n"},
+		{[]string{"sort", "--unique", "1.0.0", "1.0.0", "0.1.0"}, "", "0.1.0\n1.0.0\n"},
+		// No versions and no default
+		{[]string{"sort", "-"}, "", ""},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		code, err := routeCmd(tt.args, dir, strings.NewReader(tt.stdin), &out, io.Discard)
+		if code != 0 || err != nil {
+			t.Errorf("%q: exit code %d, %v", tt.args, code, err)
+		}
+		if out.String() != tt.out {
+			t.Errorf("%q: output %q, want %q", tt.args, out.String(), tt.out)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/asciimoth/version/pkg/version"
+)
+
+// `sync` subcommand handler.
+func cmdSync(
+	group version.SourceGroup,
+	_ []string,
+	srcs []string,
+	ver []semver.Version,
+	opts cmdOpts,
+	out io.Writer,
+) (int, error) {
+	if len(ver) > 0 {
+		return 1, errors.New("this command accepts no version args")
+	}
+	changes, err := group.Sync(srcs, opts.has("dry-run"))
+	for _, c := range changes {
+		from := "none"
+		if c.From != nil {
+			s, e := formatVersion(group, c.From, opts)
+			if e != nil {
+				return 1, e
+			}
+			from = s
+		}
+		to, e := formatVersion(group, c.To, opts)
+		if e != nil {
+			return 1, e
+		}
+		_, e = fmt.Fprintf(out, "%s: %s -> %s\n", c.Name, from, to)
+		if e != nil {
+			return 1, e
+		}
+	}
+	if err != nil {
+		return 1, err
+	}
+	return 0, nil
+}